@@ -0,0 +1,255 @@
+package mini_orm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Array wrap go slice as postgres array argument e.g. Array([]string{"a", "b c"}) => {"a","b c"}
+func Array(v interface{}) driver.Valuer {
+	return pgArray{v}
+}
+
+// pgArray postgres array literal valuer
+type pgArray struct {
+	value interface{}
+}
+
+// Value impl driver.Valuer
+func (a pgArray) Value() (driver.Value, error) {
+	v := reflect.ValueOf(a.value)
+	if !v.IsValid() || (v.Kind() == reflect.Slice && v.IsNil()) {
+		return nil, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("can not convert %T to postgres array", a.value)
+	}
+	buf := &bytes.Buffer{}
+	if err := writeArray(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func writeArray(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeArrayElem(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeArrayElem(buf *bytes.Buffer, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			buf.WriteString("NULL")
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch d := v.Interface().(type) {
+	case time.Time:
+		buf.WriteString(quoteArrayElem(d.Format(time.RFC3339Nano)))
+		return nil
+	case []byte:
+		buf.WriteString(quoteArrayElem(string(d)))
+		return nil
+	case driver.Valuer:
+		dv, err := d.Value()
+		if err != nil {
+			return err
+		}
+		if dv == nil {
+			buf.WriteString("NULL")
+			return nil
+		}
+		return writeArrayElem(buf, reflect.ValueOf(dv))
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return writeArray(buf, v)
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteString("t")
+		} else {
+			buf.WriteString("f")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	default:
+		buf.WriteString(quoteArrayElem(fmt.Sprint(v.Interface())))
+	}
+	return nil
+}
+
+func quoteArrayElem(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// parseArray parse one dimension postgres array e.g. {a,"b c",NULL}, nil element is NULL
+func parseArray(src string) ([]*string, error) {
+	if strings.HasPrefix(src, "[") {
+		// array with dimension decoration e.g. [0:1]={1,2}
+		if idx := strings.Index(src, "="); idx >= 0 {
+			src = src[idx+1:]
+		}
+	}
+	if len(src) < 2 || src[0] != '{' || src[len(src)-1] != '}' {
+		return nil, fmt.Errorf("can not parse postgres array %s", src)
+	}
+	elems := make([]*string, 0)
+	body := src[1 : len(src)-1]
+	if body == "" {
+		return elems, nil
+	}
+	for i := 0; i <= len(body); {
+		if i < len(body) && body[i] == '{' {
+			return nil, fmt.Errorf("multi dimension postgres array not support %s", src)
+		}
+		if i < len(body) && body[i] == '"' {
+			buf := strings.Builder{}
+			i++
+			for i < len(body) && body[i] != '"' {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				buf.WriteByte(body[i])
+				i++
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("can not parse postgres array %s", src)
+			}
+			elem := buf.String()
+			elems = append(elems, &elem)
+			i++
+		} else {
+			end := strings.IndexByte(body[i:], ',')
+			if end < 0 {
+				end = len(body) - i
+			}
+			elem := strings.TrimSpace(body[i : i+end])
+			if strings.EqualFold(elem, "NULL") {
+				elems = append(elems, nil)
+			} else {
+				elems = append(elems, &elem)
+			}
+			i += end
+		}
+		if i < len(body) && body[i] != ',' {
+			return nil, fmt.Errorf("can not parse postgres array %s", src)
+		}
+		i++
+	}
+	return elems, nil
+}
+
+// setArray set elements of postgres array to slice field
+func setArray(ff reflect.Value, src string) error {
+	elems, err := parseArray(src)
+	if err != nil {
+		return err
+	}
+	elemType := ff.Type().Elem()
+	dest := reflect.MakeSlice(ff.Type(), len(elems), len(elems))
+	for i, e := range elems {
+		if e == nil {
+			continue
+		}
+		ev := dest.Index(i)
+		if elemType.Kind() == reflect.Ptr {
+			ev.Set(reflect.New(elemType.Elem()))
+			ev = ev.Elem()
+		}
+		switch ev.Kind() {
+		case reflect.String:
+			ev.SetString(*e)
+		case reflect.Bool:
+			ev.SetBool(*e == "t" || *e == "true")
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v, err := strconv.ParseInt(*e, 10, 64)
+			if err != nil {
+				return err
+			}
+			ev.SetInt(v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v, err := strconv.ParseUint(*e, 10, 64)
+			if err != nil {
+				return err
+			}
+			ev.SetUint(v)
+		case reflect.Float32, reflect.Float64:
+			v, err := strconv.ParseFloat(*e, 64)
+			if err != nil {
+				return err
+			}
+			ev.SetFloat(v)
+		default:
+			return fmt.Errorf("postgres array element %s not support", elemType)
+		}
+	}
+	ff.Set(dest)
+	return nil
+}
+
+// AnyOf e.g. AnyOf{"id": []int64{1, 2}} => postgres id = ANY('{1,2}'), others id IN (1,2)
+type AnyOf ConditionExpr
+
+// ToSqlizer to postgres sq.Sqlizer
+func (c AnyOf) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(PostgresDialect)
+}
+
+// ToDialectSqlizer to sq.Sqlizer of dialect
+func (c AnyOf) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	if d != PostgresDialect {
+		return Eq(c).ToSqlizer()
+	}
+	e := sq.And{}
+	for _, k := range sortedKeys(c) {
+		e = append(e, sq.Expr(k+" = ANY(?)", Array(c[k])))
+	}
+	return e
+}
+
+// ArrayContains e.g. ArrayContains{"tags": []string{"go"}} => tags @> '{"go"}'
+type ArrayContains ConditionExpr
+
+// ToSqlizer to sq.Sqlizer
+func (c ArrayContains) ToSqlizer() sq.Sqlizer {
+	return arrayOperator(c, "@>")
+}
+
+// ArrayOverlaps e.g. ArrayOverlaps{"tags": []string{"go", "sql"}} => tags && '{"go","sql"}'
+type ArrayOverlaps ConditionExpr
+
+// ToSqlizer to sq.Sqlizer
+func (c ArrayOverlaps) ToSqlizer() sq.Sqlizer {
+	return arrayOperator(c, "&&")
+}
+
+func arrayOperator(c map[string]interface{}, operator string) sq.Sqlizer {
+	e := sq.And{}
+	for _, k := range sortedKeys(c) {
+		e = append(e, sq.Expr(fmt.Sprintf("%s %s ?", k, operator), Array(c[k])))
+	}
+	return e
+}
@@ -0,0 +1,111 @@
+package mini_orm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrayValue(t *testing.T) {
+	v, err := Array([]string{"go", `say "hi"`, `a\b`, "x,y"}).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, v, `{"go","say \"hi\"","a\\b","x,y"}`)
+	name := "laojun"
+	v, err = Array([]*string{&name, nil}).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, v, `{"laojun",NULL}`)
+	v, err = Array([]int64{1, 2, 3}).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, v, "{1,2,3}")
+	v, err = Array([]bool{true, false}).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, v, "{t,f}")
+	v, err = Array([]string(nil)).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, v, nil)
+}
+
+func TestSetArray(t *testing.T) {
+	tags := make([]string, 0)
+	err := setArray(reflect.ValueOf(&tags).Elem(), `{go,"say \"hi\"","a\\b","x,y"}`)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tags, []string{"go", `say "hi"`, `a\b`, "x,y"})
+
+	ids := make([]int64, 0)
+	err = setArray(reflect.ValueOf(&ids).Elem(), "[0:2]={1,2,3}")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids, []int64{1, 2, 3})
+
+	names := make([]*string, 0)
+	err = setArray(reflect.ValueOf(&names).Elem(), "{laojun,NULL}")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *names[0], "laojun")
+	assert.Equal(t, names[1], (*string)(nil))
+
+	err = setArray(reflect.ValueOf(&ids).Elem(), "{{1,2},{3,4}}")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestArrayConditions(t *testing.T) {
+	st := (&Statement{}).Select().From("post").Where(AnyOf{"id": []int64{1, 2}}, ArrayContains{"tags": []string{"go"}}, ArrayOverlaps{"tags": []string{"sql", "orm"}})
+	sql, args, err := st.Clone().SetDialect(PostgresDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM post WHERE (id = ANY($1)) AND (tags @> $2) AND (tags && $3)")
+	assert.Equal(t, len(args), 3)
+	tags, err := args[1].(driver.Valuer).Value()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tags, `{"go"}`)
+
+	sql, args, err = (&Statement{}).Select().From("post").Where(AnyOf{"id": []int64{1, 2}}).SetDialect(MySQLDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM post WHERE id IN (?,?)")
+	assert.Equal(t, args, []interface{}{int64(1), int64(2)})
+}
+
+// jsonTags slice stored as json, it is not postgres array
+type jsonTags []string
+
+func (t jsonTags) Value() (driver.Value, error) {
+	return json.Marshal([]string(t))
+}
+
+func (t *jsonTags) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("jsonTags expects []byte, got %T", src)
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+type taggedPost struct {
+	ID   int64 `sql:"pk,autoincr"`
+	Tags []string
+	Meta jsonTags
+}
+
+func (p *taggedPost) TableName() string {
+	return "tagged_post"
+}
+
+func TestArrayValuerAndScanner(t *testing.T) {
+	s := dryRunSession(nil)
+	_, err := s.Insert(&taggedPost{Tags: []string{"go"}, Meta: jsonTags{"a"}})
+	assert.Equal(t, err, nil)
+	args := lastDryRun(t, s).Args
+	assert.Equal(t, args[0], Array([]string{"go"}))
+	assert.Equal(t, args[1], jsonTags{"a"})
+
+	m, err := modelOf(reflect.TypeOf(taggedPost{}))
+	assert.Equal(t, err, nil)
+	tags, meta := []byte(`{go,sql}`), []byte(`["a","b"]`)
+	p := &taggedPost{}
+	sc := &Scanner{fields: []string{"tags", "meta"}, Model: m}
+	err = sc.SetEntity([]interface{}{&tags, &meta}, reflect.ValueOf(p).Elem())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, p.Tags, []string{"go", "sql"})
+	assert.Equal(t, p.Meta, jsonTags{"a", "b"})
+}
@@ -0,0 +1,114 @@
+package mini_orm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// sqlCommentKey context key of sql comment tags
+type sqlCommentKey struct{}
+
+// WithSQLComment return ctx with key/value tag e.g. route, request id or caller, tags of
+// ctx are rendered as sqlcommenter comment of every query executed by session of ctx,
+// those queries are not prepared by statement cache as their SQL is unique per request
+func WithSQLComment(ctx context.Context, key, value string) context.Context {
+	tags := make(map[string]string)
+	for k, v := range SQLCommentsFrom(ctx) {
+		tags[k] = v
+	}
+	tags[key] = value
+	return context.WithValue(ctx, sqlCommentKey{}, tags)
+}
+
+// SQLCommentsFrom return sql comment tags of ctx
+func SQLCommentsFrom(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(sqlCommentKey{}).(map[string]string)
+	return tags
+}
+
+// sqlComment serialize tags by sqlcommenter format e.g. /*action='list',route='%2Fbooks'*/,
+// keys are sorted and keys, values are url encoded so comment can not be closed by tags
+func sqlComment(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(tags))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", commentEscape(k), commentEscape(tags[k])))
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// commentEscape url encode s with %20 for space, quote is encoded by url encoding too
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// hintComment return optimizer hints comment e.g. /*+ SET_VAR(sort_buffer_size = 16M) */,
+// postgres hints are index scan hints of pg_hint_plan
+func (st *Statement) hintComment() (string, error) {
+	hints := make([]string, 0, len(st.hints)+1)
+	for _, h := range st.hints {
+		if strings.Contains(h, "*/") || strings.Contains(h, "/*") {
+			return "", fmt.Errorf("%w: %s", InvalidHint, h)
+		}
+		hints = append(hints, h)
+	}
+	for _, idx := range st.indexes {
+		if !identifier.MatchString(idx) {
+			return "", fmt.Errorf("%w: index %s", InvalidHint, idx)
+		}
+	}
+	if len(st.indexes) > 0 && st.dialect == PostgresDialect {
+		hints = append(hints, fmt.Sprintf("IndexScan(%s %s)", st.tableAlias(), strings.Join(st.indexes, " ")))
+	}
+	if len(hints) == 0 {
+		return "", nil
+	}
+	return "/*+ " + strings.Join(hints, " ") + " */", nil
+}
+
+// tableAlias return alias of table e.g. "c" of "codebook c", or table itself
+func (st *Statement) tableAlias() string {
+	fields := strings.Fields(st.table)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// tableExpr return table of FROM clause, mysql index hints follow the table
+func (st *Statement) tableExpr() string {
+	if len(st.indexes) > 0 && st.dialect == MySQLDialect && st.stType == SelectStatement {
+		return fmt.Sprintf("%s USE INDEX (%s)", st.table, strings.Join(st.indexes, ", "))
+	}
+	return st.table
+}
+
+// withHint put hints comment into query, postgres(pg_hint_plan) read hints at head of
+// query, mysql read hints after the first keyword of statement
+func (st *Statement) withHint(query string) (string, error) {
+	hint, err := st.hintComment()
+	if err != nil || hint == "" {
+		return query, err
+	}
+	if st.dialect == PostgresDialect {
+		return hint + " " + query, nil
+	}
+	i := strings.IndexByte(query, ' ')
+	if i < 0 {
+		return query + " " + hint, nil
+	}
+	return query[:i] + " " + hint + query[i:], nil
+}
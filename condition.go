@@ -1,6 +1,9 @@
 package mini_orm
 
 import (
+	"fmt"
+	"strings"
+
 	sq "github.com/Masterminds/squirrel"
 )
 
@@ -116,5 +119,96 @@ func (c GTE) ToSqlizer() sq.Sqlizer {
 // AND and expr
 type AND []Sqlizer
 
+// ToSqlizer to sq.And, so AND could be nested in OR
+func (c AND) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(UnknownDialect)
+}
+
+// ToDialectSqlizer to sq.And with dialect conditions of dialect
+func (c AND) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	e := sq.And{}
+	for _, v := range c {
+		e = append(e, toDialectSqlizer(v, d))
+	}
+	return e
+}
+
 // OR or expr
 type OR []Sqlizer
+
+// ToSqlizer to sq.Or, so OR could be nested in AND
+func (c OR) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(UnknownDialect)
+}
+
+// ToDialectSqlizer to sq.Or with dialect conditions of dialect
+func (c OR) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	e := sq.Or{}
+	for _, v := range c {
+		e = append(e, toDialectSqlizer(v, d))
+	}
+	return e
+}
+
+func toDialectSqlizer(c Sqlizer, d Dialect) sq.Sqlizer {
+	if ds, ok := c.(DialectSqlizer); ok {
+		return ds.ToDialectSqlizer(d)
+	}
+	return c.ToSqlizer()
+}
+
+// TupleIn e.g. TupleIn{Columns: []string{"tenant_id", "id"}, Values: [][]interface{}{{1, 2}, {1, 3}}}
+// postgres and mysql => (tenant_id,id) IN ((1,2),(1,3)), others (tenant_id = 1 AND id = 2) OR (...)
+type TupleIn struct {
+	Columns []string
+	Values  [][]interface{}
+}
+
+// ToSqlizer to postgres sq.Sqlizer
+func (c TupleIn) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(PostgresDialect)
+}
+
+// ToDialectSqlizer row value IN for postgres and mysql, OR of AND for others, every
+// row of values should have a value per column, otherwise SQL of it returns error
+func (c TupleIn) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	if len(c.Columns) == 0 {
+		return errSqlizer{fmt.Errorf("%w: no columns", TupleInMismatch)}
+	}
+	for i, v := range c.Values {
+		if len(v) != len(c.Columns) {
+			return errSqlizer{fmt.Errorf("%w: row %d has %d values of columns %v", TupleInMismatch, i, len(v), c.Columns)}
+		}
+	}
+	if len(c.Values) == 0 {
+		return sq.Expr("(1=0)")
+	}
+	if d != PostgresDialect && d != MySQLDialect {
+		or := sq.Or{}
+		for _, v := range c.Values {
+			and := sq.And{}
+			for i, column := range c.Columns {
+				and = append(and, sq.Eq{column: v[i]})
+			}
+			or = append(or, and)
+		}
+		return or
+	}
+	row := "(" + strings.TrimSuffix(strings.Repeat("?,", len(c.Columns)), ",") + ")"
+	rows := make([]string, 0, len(c.Values))
+	args := make([]interface{}, 0, len(c.Values)*len(c.Columns))
+	for _, v := range c.Values {
+		rows = append(rows, row)
+		args = append(args, v...)
+	}
+	return sq.Expr(fmt.Sprintf("(%s) IN (%s)", strings.Join(c.Columns, ","), strings.Join(rows, ",")), args...)
+}
+
+// errSqlizer condition of invalid input, the error is returned by ToSql
+type errSqlizer struct {
+	err error
+}
+
+func (e errSqlizer) ToSql() (string, []interface{}, error) {
+	return "", nil, e.err
+}
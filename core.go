@@ -5,14 +5,19 @@ import (
 	"fmt"
 	"strings"
 	"sync/atomic"
+	"time"
 )
 
 // DB sql driver that support master and slaves
 type DB struct {
-	master   *sql.DB
-	slaves   []*sql.DB
-	nextIdx  uint64
-	EnableMS bool
+	master    *sql.DB
+	slaves    []*sql.DB
+	nextIdx   uint64
+	dialect   Dialect
+	stmtCache *stmtCache
+	clock     func() time.Time
+	precision time.Duration
+	EnableMS  bool
 }
 
 func formatDSN(dsn string) string {
@@ -28,7 +33,7 @@ func Open(driverName, dataSourceName string) (*DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &DB{master: db, slaves: nil, EnableMS: false}, nil
+	return &DB{master: db, slaves: nil, dialect: DialectOf(driverName), EnableMS: false}, nil
 }
 
 // OpenMasterAndSlaves return DB instance
@@ -45,7 +50,7 @@ func OpenMasterAndSlaves(driverName, master string, slaves []string) (*DB, error
 		}
 		sdbs = append(sdbs, sdb)
 	}
-	return &DB{master: mdb, slaves: sdbs, EnableMS: true}, nil
+	return &DB{master: mdb, slaves: sdbs, dialect: DialectOf(driverName), EnableMS: true}, nil
 }
 
 // SetMaxIdleConns set max idle conns
@@ -68,6 +73,11 @@ func (db *DB) SetMaxOpenConns(n int) {
 	}
 }
 
+// Dialect return sql dialect of driver
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
 // Master return master
 func (db *DB) Master() *sql.DB {
 	return db.master
@@ -75,18 +85,53 @@ func (db *DB) Master() *sql.DB {
 
 // Slave return slave
 func (db *DB) Slave() *sql.DB {
+	_, s := db.slaveNode()
+	return s
+}
+
+// slaveNode return slave with its node name used as prepared statement cache key
+func (db *DB) slaveNode() (string, *sql.DB) {
 	if db.EnableMS {
 		slaveNum := uint64(len(db.slaves))
 		if slaveNum == 0 {
-			return db.master
+			return "master", db.master
 		}
-		return db.slaves[atomic.AddUint64(&db.nextIdx, 1)%slaveNum]
+		idx := atomic.AddUint64(&db.nextIdx, 1) % slaveNum
+		return fmt.Sprintf("slave:%d", idx), db.slaves[idx]
 	}
-	return db.Master()
+	return "master", db.Master()
+}
+
+// SetStmtCacheSize set max prepared statements cached per DB, 0 disable the cache,
+// it should be set before DB is used, queries with sql comment tags of ctx are not cached
+func (db *DB) SetStmtCacheSize(n int) {
+	if n <= 0 {
+		if db.stmtCache != nil {
+			db.stmtCache.close()
+			db.stmtCache = nil
+		}
+		return
+	}
+	if db.stmtCache == nil {
+		db.stmtCache = newStmtCache(n)
+		return
+	}
+	db.stmtCache.resize(n)
+}
+
+// StmtCacheStats return statistics of prepared statement cache
+func (db *DB) StmtCacheStats() StmtCacheStats {
+	if db.stmtCache == nil {
+		return StmtCacheStats{}
+	}
+	return db.stmtCache.stats()
 }
 
 // Close impl Conn close method
 func (db *DB) Close() error {
+	if db.stmtCache != nil {
+		db.stmtCache.close()
+	}
 	err := db.master.Close()
 	if err != nil {
 		return err
@@ -0,0 +1,274 @@
+package mini_orm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CursorPage cursors of the page returned by FindAllCursor, empty if no more page
+type CursorPage struct {
+	Next string
+	Prev string
+}
+
+// cursorColumn keyset column e.g. "created_at desc"
+type cursorColumn struct {
+	name string
+	desc bool
+}
+
+// cursorQuery keyset pagination of session
+type cursorQuery struct {
+	columns []cursorColumn
+	after   string
+	before  string
+}
+
+// cursorValue typed value encoded in cursor token
+type cursorValue struct {
+	Type  string      `json:"t"`
+	Value interface{} `json:"v"`
+}
+
+// Cursor set keyset columns used by FindAllCursor e.g. Cursor("created_at desc", "id desc"),
+// columns should be not null and the last one should be unique
+func (s *Session) Cursor(orderColumns ...string) *Session {
+	s.initCursor()
+	s.cursor.columns = make([]cursorColumn, 0, len(orderColumns))
+	for _, c := range orderColumns {
+		parts := strings.Fields(c)
+		if len(parts) == 0 {
+			continue
+		}
+		desc := len(parts) > 1 && strings.ToUpper(parts[1]) == "DESC"
+		s.cursor.columns = append(s.cursor.columns, cursorColumn{parts[0], desc})
+	}
+	return s
+}
+
+// After fetch page after the cursor token
+func (s *Session) After(token string) *Session {
+	s.initCursor()
+	s.cursor.after = token
+	return s
+}
+
+// Before fetch page before the cursor token
+func (s *Session) Before(token string) *Session {
+	s.initCursor()
+	s.cursor.before = token
+	return s
+}
+
+func (s *Session) initCursor() {
+	if s.cursor == nil {
+		s.cursor = &cursorQuery{}
+	}
+}
+
+// FindAllCursor FindAll with keyset pagination set by Cursor, After and Before,
+// Limit is the page size
+func (s *Session) FindAllCursor(dest interface{}) (*CursorPage, error) {
+	s.initStatemnt()
+	cursor := s.cursor
+	s.cursor = nil
+	if cursor == nil || len(cursor.columns) == 0 {
+		s.resetStatement()
+		return nil, CursorColumnsNotSet
+	}
+	scanner, err := NewScanner(dest)
+	if err != nil {
+		s.resetStatement()
+		return nil, err
+	}
+	if scanner.entityPointer.Kind() != reflect.Slice {
+		s.resetStatement()
+		return nil, FindAllExpectSlice
+	}
+	backward := cursor.before != ""
+	token := cursor.after
+	if backward {
+		token = cursor.before
+	}
+	if token != "" {
+		values, err := decodeCursor(token, len(cursor.columns))
+		if err != nil {
+			s.resetStatement()
+			return nil, err
+		}
+		s.Where(keysetCondition(cursor.columns, values, backward))
+	}
+	for _, c := range cursor.columns {
+		if c.desc != backward {
+			s.statement.OrderBy(c.name, Desc)
+		} else {
+			s.statement.OrderBy(c.name, Asc)
+		}
+	}
+	limit := s.statement.limit
+	if limit > 0 {
+		s.statement.Limit(limit + 1)
+	}
+	if err := s.FindAll(dest); err != nil {
+		return nil, err
+	}
+
+	rows := scanner.entityPointer
+	hasMore := limit > 0 && uint64(rows.Len()) > limit
+	if hasMore {
+		rows.Set(rows.Slice(0, int(limit)))
+	}
+	if backward {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	page := &CursorPage{}
+	if rows.Len() == 0 {
+		return page, nil
+	}
+	first, err := encodeCursor(scanner.Model, cursor.columns, rows.Index(0))
+	if err != nil {
+		return nil, err
+	}
+	last, err := encodeCursor(scanner.Model, cursor.columns, rows.Index(rows.Len()-1))
+	if err != nil {
+		return nil, err
+	}
+	if backward {
+		page.Next = last
+		if hasMore {
+			page.Prev = first
+		}
+	} else {
+		if token != "" {
+			page.Prev = first
+		}
+		if hasMore {
+			page.Next = last
+		}
+	}
+	return page, nil
+}
+
+// keysetCondition gen (a > ?) OR (a = ? AND b > ?) ..., compare is reversed for desc column and backward
+func keysetCondition(columns []cursorColumn, values []interface{}, backward bool) Sqlizer {
+	or := OR{}
+	for i, c := range columns {
+		and := AND{}
+		for j := 0; j < i; j++ {
+			and = append(and, Eq{columns[j].name: values[j]})
+		}
+		if c.desc != backward {
+			and = append(and, LT{c.name: values[i]})
+		} else {
+			and = append(and, GT{c.name: values[i]})
+		}
+		or = append(or, and)
+	}
+	return or
+}
+
+// encodeCursor encode keyset column values of row to opaque token
+func encodeCursor(m *Model, columns []cursorColumn, row reflect.Value) (string, error) {
+	row = reflect.Indirect(row)
+	values := make([]cursorValue, 0, len(columns))
+	for _, c := range columns {
+		name := c.name
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			name = name[idx+1:]
+		}
+		f, ok := m.Fields[name]
+		if !ok {
+			return "", fmt.Errorf("cursor column %s not found in model", c.name)
+		}
+		values = append(values, toCursorValue(reflect.Indirect(f.value(row))))
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func toCursorValue(v reflect.Value) cursorValue {
+	if !v.IsValid() {
+		return cursorValue{"null", nil}
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return cursorValue{"time", t.Format(time.RFC3339Nano)}
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{"int", v.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{"uint", v.Uint()}
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{"float", v.Float()}
+	case reflect.Bool:
+		return cursorValue{"bool", v.Bool()}
+	default:
+		return cursorValue{"string", fmt.Sprint(v.Interface())}
+	}
+}
+
+// decodeCursor decode token to keyset column values
+func decodeCursor(token string, n int) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, InvalidCursor
+	}
+	raws := make([]struct {
+		Type  string          `json:"t"`
+		Value json.RawMessage `json:"v"`
+	}, 0)
+	if err := json.Unmarshal(b, &raws); err != nil || len(raws) != n {
+		return nil, InvalidCursor
+	}
+	values := make([]interface{}, 0, n)
+	for _, r := range raws {
+		var err error
+		switch r.Type {
+		case "null":
+			values = append(values, nil)
+		case "time":
+			var s string
+			if err = json.Unmarshal(r.Value, &s); err == nil {
+				var t time.Time
+				t, err = time.Parse(time.RFC3339Nano, s)
+				values = append(values, t)
+			}
+		case "int":
+			var i int64
+			err = json.Unmarshal(r.Value, &i)
+			values = append(values, i)
+		case "uint":
+			var u uint64
+			err = json.Unmarshal(r.Value, &u)
+			values = append(values, u)
+		case "float":
+			var f float64
+			err = json.Unmarshal(r.Value, &f)
+			values = append(values, f)
+		case "bool":
+			var b bool
+			err = json.Unmarshal(r.Value, &b)
+			values = append(values, b)
+		case "string":
+			var s string
+			err = json.Unmarshal(r.Value, &s)
+			values = append(values, s)
+		default:
+			err = InvalidCursor
+		}
+		if err != nil {
+			return nil, InvalidCursor
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,46 @@
+package mini_orm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	columns := []cursorColumn{{"created_at", true}, {"id", false}}
+	st := (&Statement{}).Select().From("codebook").Where(keysetCondition(columns, []interface{}{"2020-01-01", 7}, false))
+	sql, args, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE ((created_at < ?) OR (created_at = ? AND id > ?))")
+	assert.Equal(t, args, []interface{}{"2020-01-01", "2020-01-01", 7})
+
+	st = (&Statement{}).Select().From("codebook").Where(keysetCondition(columns, []interface{}{"2020-01-01", 7}, true))
+	sql, _, err = st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE ((created_at > ?) OR (created_at = ? AND id < ?))")
+}
+
+type cursorBook struct {
+	ID        int64
+	Name      string
+	UpdatedAt *time.Time
+}
+
+func TestCursorEncodeDecode(t *testing.T) {
+	updatedAt := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	c := &cursorBook{ID: 7, Name: "laojun", UpdatedAt: &updatedAt}
+	m := NewModel(reflect.ValueOf(c))
+	columns := []cursorColumn{{"updated_at", true}, {"name", false}, {"cursor_book.id", false}}
+	token, err := encodeCursor(m, columns, reflect.ValueOf(c))
+	assert.Equal(t, err, nil)
+	values, err := decodeCursor(token, len(columns))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, values, []interface{}{updatedAt, "laojun", int64(7)})
+
+	_, err = decodeCursor(token, 2)
+	assert.Equal(t, err, InvalidCursor)
+	_, err = decodeCursor("not a cursor", 3)
+	assert.Equal(t, err, InvalidCursor)
+}
@@ -4,11 +4,12 @@ import "context"
 
 // Config connection
 type Config struct {
-	Driver       string
-	MasterAddr   string
-	SlavesAddr   []string
-	MaxIdleConns int
-	MaxOpenConns int
+	Driver        string
+	MasterAddr    string
+	SlavesAddr    []string
+	MaxIdleConns  int
+	MaxOpenConns  int
+	StmtCacheSize int
 }
 
 // Engine orm engine define
@@ -63,6 +64,7 @@ func New(cfg *Config) (*Engine, error) {
 	}
 	e.SetMaxIdleConns(cfg.MaxIdleConns)
 	e.SetMaxOpenConns(cfg.MaxOpenConns)
+	e.SetStmtCacheSize(cfg.StmtCacheSize)
 	return e, nil
 }
 
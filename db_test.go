@@ -1,6 +1,7 @@
 package mini_orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -99,7 +100,7 @@ func TestFindAll(t *testing.T) {
 	c := make([]*CodeBook, 0)
 	engine, err := NewEngine("postgres", dbAddr)
 	assert.Equal(t, err, nil)
-	err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).OrderBy("id desc").FindAll(&c)
+	err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).OrderBy("id", Desc).FindAll(&c)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, len(c), 3)
 	assert.Equal(t, c[1].ID, int64(9))
@@ -218,3 +219,268 @@ func TestTransactionWithError(t *testing.T) {
 	assert.Equal(t, err, nil)
 	assert.Equal(t, nc.Password, "nami")
 }
+
+func TestFindAllUnion(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*CodeBook, 0)
+	other := (&Statement{}).Select().From("codebook").Where(Eq{"name": "laojun"})
+	err = engine.NewSession().Select().From("codebook").Where(Eq{"name": "liubin"}).Union(other).OrderBy("id", Desc).FindAll(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 4)
+	assert.Equal(t, c[1].ID, int64(9))
+}
+
+type RankedCodeBook struct {
+	ID   int64  `json:"id" sql:"pk,columnName=id"`
+	Name string `json:"name"`
+	Rn   int64  `json:"rn" sql:"readOnly"`
+}
+
+func (c *RankedCodeBook) TableName() string {
+	return "codebook"
+}
+
+func TestFindAllWindow(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*RankedCodeBook, 0)
+	err = engine.NewSession().Select("id", "name").Window(RowNumber().PartitionBy("name").OrderBy("id desc").As("rn")).Where(Eq{"name": "liubin"}).OrderBy("id", Desc).FindAll(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 3)
+	assert.Equal(t, c[1].ID, int64(9))
+	assert.Equal(t, c[1].Rn, int64(2))
+}
+
+func TestSessionReuse(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	session := engine.NewSession()
+	base := session.Clone().Select().From("codebook")
+	count, err := base.Clone().Where(Eq{"name": "liubin"}).Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(3))
+	count, err = base.Clone().Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+
+	c := CodeBook{}
+	err = session.Select().Where(Eq{"name": "laojun"}).FindOne(&c)
+	assert.Equal(t, err, nil)
+	cc := make([]*CodeBook, 0)
+	err = session.Select().Where(Eq{"name": "liubin"}).FindAll(&cc)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(cc), 3)
+}
+
+func TestExplain(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*CodeBook, 0)
+	plan, err := engine.NewSession().Select().Where(Eq{"name": "liubin"}).Explain(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, plan.SQL, "SELECT * FROM codebook WHERE name = $1")
+	assert.NotEqual(t, plan.Text, "")
+	assert.NotEqual(t, plan.Parsed, nil)
+	plan, err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).ExplainAnalyze(&c)
+	assert.Equal(t, err, nil)
+	assert.Contains(t, plan.Text, "Actual Rows")
+}
+
+func TestFindAllCursor(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*CodeBook, 0)
+	page, err := engine.NewSession().Select().Cursor("id desc").Limit(2).FindAllCursor(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 2)
+	assert.Equal(t, page.Prev, "")
+	assert.NotEqual(t, page.Next, "")
+
+	next := make([]*CodeBook, 0)
+	nextPage, err := engine.NewSession().Select().Cursor("id desc").After(page.Next).Limit(2).FindAllCursor(&next)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(next), 2)
+	assert.True(t, next[0].ID < c[1].ID)
+	assert.NotEqual(t, nextPage.Prev, "")
+
+	prev := make([]*CodeBook, 0)
+	prevPage, err := engine.NewSession().Select().Cursor("id desc").Before(nextPage.Prev).Limit(2).FindAllCursor(&prev)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(prev), 2)
+	assert.Equal(t, prev[0].ID, c[0].ID)
+	assert.Equal(t, prev[1].ID, c[1].ID)
+	assert.Equal(t, prevPage.Prev, "")
+}
+
+func TestPaginate(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*CodeBook, 0)
+	page, err := engine.NewSession().Select().OrderBy("id", Desc).Paginate(2, 2, &c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, page.Total, int64(5))
+	assert.Equal(t, page.Pages, int64(3))
+	assert.True(t, page.HasNext)
+	assert.True(t, page.HasPrev)
+	assert.Equal(t, len(c), 2)
+
+	c = make([]*CodeBook, 0)
+	page, err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).OrderBy("id", Desc).Paginate(1, 10, &c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, page.Total, int64(3))
+	assert.False(t, page.HasNext)
+	assert.Equal(t, len(c), 3)
+}
+
+type ActiveCodeBook struct {
+	ID       int64  `json:"id" sql:"pk,columnName=id"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func (c *ActiveCodeBook) TableName() string {
+	return "codebook"
+}
+
+func (c *ActiveCodeBook) DefaultScopes() []Scope {
+	return []Scope{func(st *Statement) *Statement {
+		return st.Where(Eq{"name": "liubin"})
+	}}
+}
+
+func TestFindAllScopes(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*ActiveCodeBook, 0)
+	err = engine.NewSession().Select().FindAll(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 3)
+
+	desc := func(st *Statement) *Statement {
+		return st.OrderBy("id", Desc)
+	}
+	c = make([]*ActiveCodeBook, 0)
+	err = engine.NewSession().Select().Unscoped().Scopes(desc).FindAll(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 5)
+	assert.True(t, c[0].ID > c[1].ID)
+}
+
+func TestInsertFrom(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	session := engine.NewSession()
+	f := func(s *Session) (interface{}, error) {
+		source := (&Statement{}).Select("name", "password").From("codebook").Where(Eq{"name": "liubin"})
+		return s.InsertFrom("codebook", []string{"name", "password"}, source)
+	}
+	rowcount, err := session.Transaction(f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(3))
+	count, err := engine.NewSession().Select().From("codebook").Where(Eq{"name": "liubin"}).Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(6))
+}
+
+func TestValidateColumns(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	c := make([]*CodeBook, 0)
+	err = engine.NewSession().Select().Where(Eq{"nmae": "liubin"}).FindAll(&c)
+	_, ok := err.(*UnknownColumnError)
+	assert.True(t, ok)
+	count, err := engine.NewSession().Select().Model(&CodeBook{}).Where(Eq{"name": "liubin"}).Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(3))
+	_, err = engine.NewSession().Select().Model(&CodeBook{}).Where(Eq{"pasword": "qingning"}).Count()
+	assert.NotEqual(t, err, nil)
+	err = engine.NewSession().Select().SkipValidation().Where(Eq{"lower(name)": "liubin"}).FindAll(&c)
+	assert.Equal(t, err, nil)
+}
+
+func TestStmtCache(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	engine.SetStmtCacheSize(1)
+	for i := 0; i < 2; i++ {
+		c := make([]*CodeBook, 0)
+		err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).FindAll(&c)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(c), 3)
+	}
+	count, err := engine.NewSession().Select().From("codebook").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+	stats := engine.StmtCacheStats()
+	assert.Equal(t, stats.Hits, uint64(1))
+	assert.Equal(t, stats.Misses, uint64(2))
+	assert.Equal(t, stats.Evictions, uint64(1))
+	assert.Equal(t, stats.Size, 1)
+
+	_, err = engine.NewSession().Transaction(func(s *Session) (interface{}, error) {
+		return s.Update(&CodeBook{ID: 2, Name: "nami", Password: "lufei"})
+	})
+	assert.Equal(t, err, nil)
+}
+
+func TestChunked(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	cc := make([]*CodeBook, 0)
+	for _, name := range []string{"xiangjishi", "suolong", "nami", "wusuopu", "qiaoba"} {
+		cc = append(cc, &CodeBook{Name: name, Password: name})
+	}
+	rowcount, err := engine.NewSession().ChunkSize(8).Atomic().Insert(&cc)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(5))
+	count, err := engine.NewSession().Select().From("codebook").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(10))
+
+	books := make([]*CodeBook, 0)
+	err = engine.NewSession().Select().Where(Eq{"name": "liubin"}).FindAll(&books)
+	assert.Equal(t, err, nil)
+	rowcount, err = engine.NewSession().ChunkSize(2).Delete(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(3))
+}
+
+func TestSQLComment(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	ctx := WithSQLComment(context.Background(), "route", "/books")
+	ctx = WithSQLComment(ctx, "request_id", "42")
+	c := make([]*CodeBook, 0)
+	err = engine.NewSessionCtx(ctx).Select().Where(Eq{"name": "liubin"}).Hint("SeqScan(codebook)").FindAll(&c)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(c), 3)
+	count, err := engine.NewSessionCtx(ctx).Select().From("codebook").Comment("caller", "TestSQLComment").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+}
+
+func TestSafeMode(t *testing.T) {
+	prepareTestDatabase()
+	engine, err := NewEngine("postgres", dbAddr)
+	assert.Equal(t, err, nil)
+	_, err = engine.NewSession().Delete(&[]*CodeBook{})
+	assert.Equal(t, err, EmptyRecords)
+	_, err = engine.NewSession().Update(&CodeBook{Name: "nami"})
+	assert.Equal(t, err, ZeroPrimaryKey)
+	count, err := engine.NewSession().Select().From("codebook").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+}
@@ -0,0 +1,85 @@
+package mini_orm
+
+import (
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Dialect sql dialect of database
+type Dialect int
+
+const (
+	UnknownDialect  Dialect = 0
+	MySQLDialect    Dialect = 1
+	PostgresDialect Dialect = 2
+)
+
+// DialectOf return dialect by driver name
+func DialectOf(driverName string) Dialect {
+	switch driverName {
+	case "mysql":
+		return MySQLDialect
+	case "postgres", "pgx", "cloudsqlpostgres":
+		return PostgresDialect
+	default:
+		return UnknownDialect
+	}
+}
+
+// String dialect name
+func (d Dialect) String() string {
+	switch d {
+	case MySQLDialect:
+		return "mysql"
+	case PostgresDialect:
+		return "postgres"
+	default:
+		return "unknown"
+	}
+}
+
+// placeholder return placeholder format used by driver
+func (d Dialect) placeholder() sq.PlaceholderFormat {
+	if d == PostgresDialect {
+		return sq.Dollar
+	}
+	return sq.Question
+}
+
+// explain return EXPLAIN prefix and whether the plan is returned as JSON
+func (d Dialect) explain(analyze bool) (string, bool) {
+	switch d {
+	case PostgresDialect:
+		if analyze {
+			return "EXPLAIN (ANALYZE, FORMAT JSON)", true
+		}
+		return "EXPLAIN (FORMAT JSON)", true
+	case MySQLDialect:
+		// mysql EXPLAIN ANALYZE only support TREE format
+		if analyze {
+			return "EXPLAIN ANALYZE", false
+		}
+		return "EXPLAIN FORMAT=JSON", true
+	default:
+		if analyze {
+			return "EXPLAIN ANALYZE", false
+		}
+		return "EXPLAIN", false
+	}
+}
+
+// maxParams return max bind parameters of one statement
+func (d Dialect) maxParams() int {
+	switch d {
+	case PostgresDialect, MySQLDialect:
+		return 65535
+	default:
+		// sqlite default SQLITE_MAX_VARIABLE_NUMBER
+		return 999
+	}
+}
+
+// valuesDefault return whether DEFAULT keyword is accepted as value of multi-row VALUES,
+// sqlite rejects it
+func (d Dialect) valuesDefault() bool {
+	return d == PostgresDialect || d == MySQLDialect
+}
@@ -0,0 +1,50 @@
+package mini_orm
+
+import "sync"
+
+// DryRunStatement SQL and args recorded instead of executed in dry run mode
+type DryRunStatement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// dryRunRecorder statements recorded by session and its clones
+type dryRunRecorder struct {
+	mu         sync.Mutex
+	statements []DryRunStatement
+}
+
+func (r *dryRunRecorder) record(query string, args []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, DryRunStatement{SQL: query, Args: args})
+}
+
+// DryRun make FindOne, FindAll, Insert, Update, Delete and Count build statements
+// as usual but record SQL and args instead of executing them, dest is not changed
+// and affected rows and count are 0
+func (s *Session) DryRun() *Session {
+	if s.dryRun == nil {
+		s.dryRun = &dryRunRecorder{}
+	}
+	return s
+}
+
+// DryRunStatements return statements recorded in dry run mode by session and its clones
+func (s *Session) DryRunStatements() []DryRunStatement {
+	if s.dryRun == nil {
+		return nil
+	}
+	s.dryRun.mu.Lock()
+	defer s.dryRun.mu.Unlock()
+	return append([]DryRunStatement(nil), s.dryRun.statements...)
+}
+
+// recordDryRun record query and return true if session is in dry run mode
+func (s *Session) recordDryRun(query string, args []interface{}) bool {
+	if s.dryRun == nil {
+		return false
+	}
+	s.dryRun.record(query, args)
+	return true
+}
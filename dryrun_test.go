@@ -0,0 +1,84 @@
+package mini_orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dryRunBook struct {
+	ID     int64 `sql:"pk,autoincr"`
+	Name   string
+	Status string `sql:"omitempty"`
+}
+
+func (b *dryRunBook) TableName() string {
+	return "dry_run_book"
+}
+
+// dryRunSession return session of db in dry run mode, db is postgres DB if nil
+func dryRunSession(db *DB) *Session {
+	if db == nil {
+		db = &DB{dialect: PostgresDialect}
+	}
+	return (&Session{db: db, statement: &Statement{}, isAutoCommit: true}).DryRun()
+}
+
+// lastDryRun return statement recorded last by s, so each operation is checked by itself
+func lastDryRun(t *testing.T, s *Session) DryRunStatement {
+	t.Helper()
+	statements := s.DryRunStatements()
+	if len(statements) == 0 {
+		t.Fatal("no statement recorded in dry run mode")
+	}
+	return statements[len(statements)-1]
+}
+
+func TestDryRun(t *testing.T) {
+	s := dryRunSession(nil)
+	rowcount, err := s.Insert(&[]*dryRunBook{{Name: "liubin"}, {Name: "laojun", Status: "active"}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(0))
+
+	book := &dryRunBook{}
+	err = s.Select("id", "name").Where(Eq{"name": "liubin"}).FindOne(book)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, book.ID, int64(0))
+
+	books := make([]*dryRunBook, 0)
+	page, err := s.Select().OrderBy("id", Desc).Paginate(2, 10, &books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, page.Total, int64(0))
+	assert.Equal(t, len(books), 0)
+
+	statements := s.DryRunStatements()
+	assert.Equal(t, len(statements), 4)
+	assert.Equal(t, statements[0], DryRunStatement{SQL: "INSERT INTO dry_run_book (name,status) VALUES ($1,DEFAULT),($2,$3)", Args: []interface{}{"liubin", "laojun", "active"}})
+	assert.Equal(t, statements[1], DryRunStatement{SQL: "SELECT id, name FROM dry_run_book WHERE name = $1 LIMIT 1", Args: []interface{}{"liubin"}})
+	assert.Equal(t, statements[2], DryRunStatement{SQL: "SELECT count(*) FROM dry_run_book"})
+	assert.Equal(t, statements[3].SQL, "SELECT * FROM dry_run_book ORDER BY id DESC LIMIT 10 OFFSET 10")
+
+	rowcount, err = s.ChunkSize(2).Delete(&[]dryRunBook{{ID: 1}, {ID: 2}, {ID: 3}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(0))
+	_, err = s.Update(&[]*dryRunBook{{ID: 1, Name: "nami"}, {ID: 2, Name: "wusuopu", Status: "deleted"}})
+	assert.Equal(t, err, nil)
+	statements = s.DryRunStatements()[4:]
+	assert.Equal(t, statements, []DryRunStatement{
+		{SQL: "DELETE FROM dry_run_book WHERE id IN ($1,$2)", Args: []interface{}{int64(1), int64(2)}},
+		{SQL: "DELETE FROM dry_run_book WHERE id IN ($1)", Args: []interface{}{int64(3)}},
+		{SQL: "UPDATE dry_run_book SET name = $1 WHERE id = $2", Args: []interface{}{"nami", int64(1)}},
+		{SQL: "UPDATE dry_run_book SET name = $1, status = $2 WHERE id = $3", Args: []interface{}{"wusuopu", "deleted", int64(2)}},
+	})
+}
+
+func TestDryRunInsertWithoutDefault(t *testing.T) {
+	s := dryRunSession(&DB{dialect: UnknownDialect})
+	_, err := s.Insert(&[]*dryRunBook{{Name: "liubin"}, {Name: "laojun", Status: "active"}, {ID: 7, Name: "nami"}, {Name: "wusuopu"}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.DryRunStatements(), []DryRunStatement{
+		{SQL: "INSERT INTO dry_run_book (name) VALUES (?),(?)", Args: []interface{}{"liubin", "wusuopu"}},
+		{SQL: "INSERT INTO dry_run_book (name,status) VALUES (?,?)", Args: []interface{}{"laojun", "active"}},
+		{SQL: "INSERT INTO dry_run_book (id,name) VALUES (?,?)", Args: []interface{}{int64(7), "nami"}},
+	})
+}
@@ -2,12 +2,16 @@ package mini_orm
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 var (
 	CFBNotAllowEmpty            = errors.New("config not allow empty")
 	StatementTableNotSet        = errors.New("statement table not set")
 	StatementTypeNotSet         = errors.New("statement type not set")
+	SetOperationExpectSelect    = errors.New("set operation expect select statement")
+	InsertSelectExpectSelect    = errors.New("insert select expect select statement")
 	ScannerRowsPointerNil       = errors.New("Scanner rows could not be nil pointer")
 	ScannerEntityNeedCanSet     = errors.New("Entity need can set")
 	ScannerEntiryTypeNotSupport = errors.New("Scanner Entity not support. it should be struct or slice")
@@ -19,4 +23,62 @@ var (
 	ModelMissingPrimaryKey      = errors.New("model missing primary key")
 	ModelNotSupportType         = errors.New("model onl support model{} or &model{}")
 	RecordNotFound              = errors.New("record not found")
+	CursorColumnsNotSet         = errors.New("cursor columns not set")
+	InvalidCursor               = errors.New("invalid cursor")
+	OrderByColumnNotAllowed     = errors.New("order by column not allowed")
+	PageSizeInvalid             = errors.New("page size should be greater than 0")
+	InvalidHint                 = errors.New("invalid hint")
+	InterpolateArgsMismatch     = errors.New("placeholders not match args")
+	GlobalUpdateOrDelete        = errors.New("update or delete without conditions, call AllowGlobal to allow it")
+	EmptyRecords                = errors.New("records could not be empty")
+	ZeroPrimaryKey              = errors.New("primary key could not be zero value")
+	InvalidTag                  = errors.New("invalid sql tag")
+	PrimaryKeyMismatch          = errors.New("values not match primary keys")
+	ModelMissingDeletedAt       = errors.New("model missing deletedAt field")
+	TupleInMismatch             = errors.New("values not match columns of TupleIn")
 )
+
+// UnknownColumnError columns used by query are not fields of model
+type UnknownColumnError struct {
+	Table       string
+	Columns     []string
+	Suggestions map[string][]string
+}
+
+func newUnknownColumnError(m *Model, columns []string) *UnknownColumnError {
+	e := &UnknownColumnError{Table: m.TableName, Columns: columns, Suggestions: make(map[string][]string)}
+	for _, c := range columns {
+		if matches := closeMatches(m, c); len(matches) > 0 {
+			e.Suggestions[c] = matches
+		}
+	}
+	return e
+}
+
+func (e *UnknownColumnError) Error() string {
+	columns := make([]string, 0, len(e.Columns))
+	for _, c := range e.Columns {
+		if s, ok := e.Suggestions[c]; ok {
+			columns = append(columns, fmt.Sprintf("%s (did you mean %s?)", c, strings.Join(s, ", ")))
+		} else {
+			columns = append(columns, c)
+		}
+	}
+	return fmt.Sprintf("unknown columns of model %s: %s", e.Table, strings.Join(columns, "; "))
+}
+
+// TagError invalid `sql` tag of model field, it is InvalidTag for errors.Is
+type TagError struct {
+	Model  string
+	Field  string
+	Tag    string
+	Reason string
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("invalid sql tag %q of %s.%s: %s", e.Tag, e.Model, e.Field, e.Reason)
+}
+
+func (e *TagError) Unwrap() error {
+	return InvalidTag
+}
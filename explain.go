@@ -0,0 +1,94 @@
+package mini_orm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Plan query plan of statement
+type Plan struct {
+	SQL  string
+	Args []interface{}
+	// Text raw plan returned by database, one line per row
+	Text string
+	// Parsed decoded plan for dialect support JSON format, nil for others
+	Parsed interface{}
+}
+
+// Explain return plan of the query FindOne(dest) or FindAll(dest) would run,
+// dest could be nil to explain statement as it is e.g. Columns("count(*)") with Model
+func (s *Session) Explain(dest interface{}) (*Plan, error) {
+	return s.explain(dest, false)
+}
+
+// ExplainAnalyze like Explain but execute the query to get actual plan
+func (s *Session) ExplainAnalyze(dest interface{}) (*Plan, error) {
+	return s.explain(dest, true)
+}
+
+func (s *Session) explain(dest interface{}, analyze bool) (*Plan, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	m, one := s.model, false
+	if dest != nil {
+		scanner, err := NewScanner(dest)
+		if err != nil {
+			return nil, err
+		}
+		one = scanner.entityPointer.Kind() == reflect.Struct
+		m = scanner.Model
+	}
+	if m != nil && s.statement.table == "" {
+		s.statement.From(m.TableName)
+	}
+	if err := s.prepareQuery(m); err != nil {
+		return nil, err
+	}
+	// limit is set after scopes as FindOne does, so the plan is of the query FindOne runs
+	if one {
+		s.Limit(1)
+	}
+	query, args, err := s.toSQL()
+	if err != nil {
+		return nil, err
+	}
+	prefix, isJSON := s.db.Dialect().explain(analyze)
+	Tracef("[Session Explain] sql: %s %s, args: %v", prefix, query, args)
+	s.initCtx()
+	rows, err := s.QueryContext(s.ctx, prefix+" "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			values[i] = &sql.NullString{}
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		cells := make([]string, 0, len(values))
+		for _, v := range values {
+			cells = append(cells, v.(*sql.NullString).String)
+		}
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	plan := &Plan{SQL: query, Args: args, Text: strings.Join(lines, "\n")}
+	if isJSON {
+		if err := json.Unmarshal([]byte(plan.Text), &plan.Parsed); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
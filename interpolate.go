@@ -0,0 +1,177 @@
+package mini_orm
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interpolate return SQL with arguments rendered as literals of dialect e.g.
+// SELECT * FROM codebook WHERE name = 'liubin', it is for debugging and logs only
+// and never execute the result
+func (st *Statement) Interpolate() (string, error) {
+	query, args, err := st.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return interpolate(st.dialect, query, args)
+}
+
+// interpolate replace placeholders of query with literals of args, placeholders in
+// quoted strings, identifiers and comments are skipped
+func interpolate(d Dialect, query string, args []interface{}) (string, error) {
+	buf := strings.Builder{}
+	used := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := closingQuote(d, query, i)
+			buf.WriteString(query[i:end])
+			i = end - 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				buf.WriteString(query[i:])
+				i = len(query)
+				continue
+			}
+			buf.WriteString(query[i : i+end+4])
+			i += end + 3
+		case c == '?' && d != PostgresDialect:
+			if strings.HasPrefix(query[i:], "??") {
+				buf.WriteString("??")
+				i++
+				continue
+			}
+			if used >= len(args) {
+				return "", fmt.Errorf("%w: placeholder %d of %d args", InterpolateArgsMismatch, used+1, len(args))
+			}
+			lit, err := literal(d, args[used])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(lit)
+			used++
+		case c == '$' && d == PostgresDialect && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			end := i + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n, _ := strconv.Atoi(query[i+1 : end])
+			if n < 1 || n > len(args) {
+				return "", fmt.Errorf("%w: placeholder $%d of %d args", InterpolateArgsMismatch, n, len(args))
+			}
+			lit, err := literal(d, args[n-1])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(lit)
+			if n > used {
+				used = n
+			}
+			i = end - 1
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	if used != len(args) {
+		return "", fmt.Errorf("%w: %d placeholders of %d args", InterpolateArgsMismatch, used, len(args))
+	}
+	return buf.String(), nil
+}
+
+// closingQuote return index after the quote closing the one at start, doubled quote
+// is escaped and backslash is escape of mysql strings
+func closingQuote(d Dialect, query string, start int) int {
+	q := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && q != '`' && d == MySQLDialect:
+			i++
+		case query[i] == q:
+			if i+1 < len(query) && query[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// literal render arg as SQL literal of dialect
+func literal(d Dialect, arg interface{}) (string, error) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "NULL", nil
+		}
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		arg = v
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return stringLiteral(d, v), nil
+	case []byte:
+		if v == nil {
+			return "NULL", nil
+		}
+		if d == PostgresDialect {
+			return `'\x` + hex.EncodeToString(v) + `'::bytea`, nil
+		}
+		return "X'" + hex.EncodeToString(v) + "'", nil
+	case time.Time:
+		if d == PostgresDialect {
+			return "'" + v.Format("2006-01-02 15:04:05.999999-07:00") + "'", nil
+		}
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'", nil
+	case bool:
+		if d == MySQLDialect {
+			if v {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return literal(d, rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return stringLiteral(d, rv.String()), nil
+	case reflect.Bool:
+		return literal(d, rv.Bool())
+	}
+	return stringLiteral(d, fmt.Sprint(arg)), nil
+}
+
+// stringLiteral quote string, mysql escapes backslash and control characters as well
+func stringLiteral(d Dialect, s string) string {
+	if d != MySQLDialect {
+		return quoteLiteral(s)
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+	return "'" + r.Replace(s) + "'"
+}
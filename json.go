@@ -0,0 +1,184 @@
+package mini_orm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var jsonIdentifier = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// DialectSqlizer condition rendered differently per dialect
+type DialectSqlizer interface {
+	ToDialectSqlizer(d Dialect) sq.Sqlizer
+}
+
+// JSONEq e.g. JSONEq{"meta->settings->theme": "dark"}
+// postgres => meta->'settings'->>'theme' = ?
+// mysql => JSON_UNQUOTE(JSON_EXTRACT(meta, '$.settings.theme')) = ?
+type JSONEq ConditionExpr
+
+// ToSqlizer to sq.Sqlizer of mysql
+func (c JSONEq) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(MySQLDialect)
+}
+
+// ToDialectSqlizer to sq.Sqlizer of dialect
+func (c JSONEq) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	e := sq.And{}
+	for _, k := range sortedKeys(c) {
+		e = append(e, sq.Expr(jsonExtract(d, k)+" = ?", c[k]))
+	}
+	return e
+}
+
+// JSONContains e.g. JSONContains{"meta->settings": map[string]interface{}{"theme": "dark"}}
+// value is marshaled to json unless it is string or []byte
+// postgres => meta->'settings' @> ?::jsonb
+// mysql => JSON_CONTAINS(meta, ?, '$.settings')
+type JSONContains ConditionExpr
+
+// ToSqlizer to sq.Sqlizer of mysql
+func (c JSONContains) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(MySQLDialect)
+}
+
+// ToDialectSqlizer to sq.Sqlizer of dialect
+func (c JSONContains) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	e := sq.And{}
+	for _, k := range sortedKeys(c) {
+		doc := jsonDocument(c[k])
+		column, keys := parseJSONPath(k)
+		if d == PostgresDialect {
+			e = append(e, sq.Expr(pgJSONPath(column, keys, false)+" @> ?::jsonb", doc))
+		} else if len(keys) > 0 {
+			e = append(e, sq.Expr(fmt.Sprintf("JSON_CONTAINS(%s, ?, ?)", column), doc, mysqlJSONPath(keys)))
+		} else {
+			e = append(e, sq.Expr(fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), doc))
+		}
+	}
+	return e
+}
+
+// JSONHasKey e.g. JSONHasKey{"meta->settings": "theme"}
+// postgres => meta->'settings' ? 'theme'
+// mysql => JSON_CONTAINS_PATH(meta, 'one', '$.settings.theme')
+type JSONHasKey ConditionExpr
+
+// ToSqlizer to sq.Sqlizer of mysql
+func (c JSONHasKey) ToSqlizer() sq.Sqlizer {
+	return c.ToDialectSqlizer(MySQLDialect)
+}
+
+// ToDialectSqlizer to sq.Sqlizer of dialect
+func (c JSONHasKey) ToDialectSqlizer(d Dialect) sq.Sqlizer {
+	e := sq.And{}
+	for _, k := range sortedKeys(c) {
+		column, keys := parseJSONPath(k)
+		key := fmt.Sprint(c[k])
+		if d == PostgresDialect {
+			// ?? is escaped ? operator of placeholder format
+			e = append(e, sq.Expr(pgJSONPath(column, keys, false)+" ?? ?", key))
+		} else {
+			e = append(e, sq.Expr(fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', ?)", column), mysqlJSONPath(append(keys, key))))
+		}
+	}
+	return e
+}
+
+// jsonColumn json path selected as column
+type jsonColumn struct {
+	path  string
+	alias string
+}
+
+// jsonExtract gen expr extract text of json path for dialect
+func jsonExtract(d Dialect, path string) string {
+	column, keys := parseJSONPath(path)
+	if len(keys) == 0 {
+		return column
+	}
+	if d == PostgresDialect {
+		return pgJSONPath(column, keys, true)
+	}
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s))", column, quoteLiteral(mysqlJSONPath(keys)))
+}
+
+// parseJSONPath split "meta->settings->theme" to meta and [settings theme]
+func parseJSONPath(path string) (string, []string) {
+	parts := strings.Split(path, "->")
+	column := strings.TrimSpace(parts[0])
+	keys := make([]string, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		keys = append(keys, strings.Trim(strings.TrimPrefix(p, ">"), " '\""))
+	}
+	return column, keys
+}
+
+// pgJSONPath gen meta->'settings'->'theme', last operator is ->> if text
+func pgJSONPath(column string, keys []string, text bool) string {
+	buf := strings.Builder{}
+	buf.WriteString(column)
+	for i, k := range keys {
+		if text && i == len(keys)-1 {
+			buf.WriteString("->>")
+		} else {
+			buf.WriteString("->")
+		}
+		if _, err := strconv.Atoi(k); err == nil {
+			buf.WriteString(k)
+		} else {
+			buf.WriteString(quoteLiteral(k))
+		}
+	}
+	return buf.String()
+}
+
+// mysqlJSONPath gen $.settings.theme, numeric key is array index e.g. $.tags[0]
+func mysqlJSONPath(keys []string) string {
+	buf := strings.Builder{}
+	buf.WriteString("$")
+	for _, k := range keys {
+		if _, err := strconv.Atoi(k); err == nil {
+			buf.WriteString("[" + k + "]")
+		} else if jsonIdentifier.MatchString(k) {
+			buf.WriteString("." + k)
+		} else {
+			buf.WriteString("." + strconv.Quote(k))
+		}
+	}
+	return buf.String()
+}
+
+// jsonDocument marshal value to json document
+func jsonDocument(v interface{}) interface{} {
+	switch d := v.(type) {
+	case string, []byte:
+		return d
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return v
+		}
+		return string(b)
+	}
+}
+
+// quoteLiteral quote string as sql literal
+func quoteLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
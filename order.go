@@ -0,0 +1,88 @@
+package mini_orm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OrderOption direction and nulls position of OrderBy
+type OrderOption int
+
+const (
+	Asc        OrderOption = 1
+	Desc       OrderOption = 2
+	NullsFirst OrderOption = 3
+	NullsLast  OrderOption = 4
+)
+
+// identifier matches column or table.column
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// orderBy item of ORDER BY, raw is spliced into SQL as it is
+type orderBy struct {
+	raw    string
+	column string
+	desc   bool
+	nulls  OrderOption
+}
+
+func newOrderBy(column string, opts ...OrderOption) orderBy {
+	o := orderBy{column: column}
+	for _, opt := range opts {
+		switch opt {
+		case Asc:
+			o.desc = false
+		case Desc:
+			o.desc = true
+		case NullsFirst, NullsLast:
+			o.nulls = opt
+		}
+	}
+	return o
+}
+
+// validate check column is identifier and in allowed columns if any
+func (o orderBy) validate(allowed map[string]bool) error {
+	if o.raw != "" {
+		return nil
+	}
+	if !identifier.MatchString(o.column) {
+		return fmt.Errorf("%w: %q", OrderByColumnNotAllowed, o.column)
+	}
+	if allowed != nil && !allowed[o.column] && !allowed[unqualified(o.column)] {
+		return fmt.Errorf("%w: %q", OrderByColumnNotAllowed, o.column)
+	}
+	return nil
+}
+
+// toSQL render item, mysql has no NULLS FIRST/LAST so it is emulated by `column IS NULL`
+func (o orderBy) toSQL(d Dialect) []string {
+	if o.raw != "" {
+		return []string{o.raw}
+	}
+	expr := o.column + " ASC"
+	if o.desc {
+		expr = o.column + " DESC"
+	}
+	switch {
+	case o.nulls == 0:
+		return []string{expr}
+	case d == MySQLDialect && o.nulls == NullsFirst:
+		return []string{o.column + " IS NULL DESC", expr}
+	case d == MySQLDialect:
+		return []string{o.column + " IS NULL ASC", expr}
+	case o.nulls == NullsFirst:
+		return []string{expr + " NULLS FIRST"}
+	default:
+		return []string{expr + " NULLS LAST"}
+	}
+}
+
+// unqualified return column without table e.g. c.id => id
+func unqualified(column string) string {
+	if idx := strings.LastIndex(column, "."); idx >= 0 {
+		return column[idx+1:]
+	}
+	return column
+}
@@ -0,0 +1,59 @@
+package mini_orm
+
+import (
+	"reflect"
+)
+
+// Page offset pagination result of Paginate
+type Page struct {
+	Page    uint64
+	Size    uint64
+	Total   int64
+	Pages   int64
+	HasNext bool
+	HasPrev bool
+}
+
+// Paginate count all records matched conditions and find records of page into dest,
+// page start from 1
+func (s *Session) Paginate(page, size uint64, dest interface{}) (*Page, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	if size == 0 {
+		return nil, PageSizeInvalid
+	}
+	if page == 0 {
+		page = 1
+	}
+	scanner, err := NewScanner(dest)
+	if err != nil {
+		return nil, err
+	}
+	if scanner.entityPointer.Kind() != reflect.Slice {
+		return nil, FindAllExpectSlice
+	}
+	if s.statement.table == "" {
+		s.statement.From(scanner.GetTableName())
+	}
+	if err := s.prepareQuery(scanner.Model); err != nil {
+		return nil, err
+	}
+	total, err := s.Clone().Count()
+	if err != nil {
+		return nil, err
+	}
+	p := &Page{Page: page, Size: size, Total: total}
+	p.Pages = (total + int64(size) - 1) / int64(size)
+	p.HasNext = int64(page) < p.Pages
+	p.HasPrev = page > 1
+	// dry run count is always 0, still build the page query to record it
+	if s.dryRun == nil && int64((page-1)*size) >= total {
+		scanner.entityPointer.Set(reflect.MakeSlice(scanner.entityPointer.Type(), 0, 0))
+		return p, nil
+	}
+	s.Limit(size).Offset((page - 1) * size)
+	if err := s.FindAll(dest); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
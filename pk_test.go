@@ -0,0 +1,54 @@
+package mini_orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type membership struct {
+	TenantID int64 `sql:"pk"`
+	UserID   int64 `sql:"pk"`
+	Role     string
+}
+
+func (m *membership) TableName() string {
+	return "membership"
+}
+
+func TestCompositePrimaryKeys(t *testing.T) {
+	s := dryRunSession(nil)
+	_, err := s.Update(&membership{TenantID: 1, UserID: 2, Role: "admin"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "UPDATE membership SET role = $1 WHERE (tenant_id = $2 AND user_id = $3)", Args: []interface{}{"admin", int64(1), int64(2)}})
+
+	_, err = s.Delete(&[]membership{{TenantID: 1, UserID: 2}, {TenantID: 1, UserID: 3}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "DELETE FROM membership WHERE (tenant_id,user_id) IN (($1,$2),($3,$4))", Args: []interface{}{int64(1), int64(2), int64(1), int64(3)}})
+
+	err = s.FindByKey(&membership{}, 1, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT * FROM membership WHERE (tenant_id = $1 AND user_id = $2) LIMIT 1", Args: []interface{}{1, 2}})
+
+	ms := make([]*membership, 0)
+	err = s.FindAllByKeys(&ms, []interface{}{1, 2}, []interface{}{1, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT * FROM membership WHERE (tenant_id,user_id) IN (($1,$2),($3,$4))", Args: []interface{}{1, 2, 1, 3}})
+
+	_, err = s.Update(&membership{TenantID: 1, Role: "admin"})
+	assert.Equal(t, err, ZeroPrimaryKey)
+	err = s.FindByKey(&membership{}, 1)
+	assert.True(t, errors.Is(err, PrimaryKeyMismatch))
+
+	sql, args, err := (&Statement{}).Select().From("membership").Where(TupleIn{Columns: []string{"tenant_id", "user_id"}, Values: [][]interface{}{{1, 2}, {1, 3}}}).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM membership WHERE ((tenant_id = ? AND user_id = ?) OR (tenant_id = ? AND user_id = ?))")
+	assert.Equal(t, args, []interface{}{1, 2, 1, 3})
+
+	short := TupleIn{Columns: []string{"tenant_id", "user_id"}, Values: [][]interface{}{{1, 2}, {1}}}
+	for _, d := range []Dialect{PostgresDialect, UnknownDialect} {
+		_, _, err = (&Statement{}).Select().From("membership").Where(short).SetDialect(d).ToSQL()
+		assert.True(t, errors.Is(err, TupleInMismatch))
+	}
+}
@@ -0,0 +1,60 @@
+package mini_orm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// modelEntry parsed model of struct type, model is set even if err is not nil
+type modelEntry struct {
+	model *Model
+	err   error
+}
+
+// models cache of model metadata by struct type, the cached model and its
+// fields are shared by all sessions and must not be modified
+var models sync.Map
+
+// modelOf return cached model of struct type t, it is parsed at first use
+func modelOf(t reflect.Type) (*Model, error) {
+	if e, ok := models.Load(t); ok {
+		entry := e.(*modelEntry)
+		return entry.model, entry.err
+	}
+	m, err := parseModel(t)
+	e, _ := models.LoadOrStore(t, &modelEntry{m, err})
+	entry := e.(*modelEntry)
+	if entry.err == nil && entry.model.SoftDelete != nil && entry.model.TableName != "" {
+		softDeleteModels.LoadOrStore(entry.model.TableName, entry.model)
+	}
+	return entry.model, entry.err
+}
+
+// softDeleteModels models with deletedAt field by table name, so queries without
+// model of the table e.g. From("book").Count() skip soft deleted rows as well
+var softDeleteModels sync.Map
+
+// Register parse and cache models upfront e.g. Register(&User{}, Book{}), it
+// returns the first error of invalid model so mistakes are found at startup.
+// Count without Model skips soft deleted rows of tables of registered models
+func Register(values ...interface{}) error {
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("%w: %T", ModelNotSupportType, v)
+		}
+		if _, err := modelOf(t); err != nil {
+			return fmt.Errorf("model %s: %w", t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Register parse and cache models upfront, see Register
+func (e *Engine) Register(values ...interface{}) error {
+	return Register(values...)
+}
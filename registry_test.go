@@ -0,0 +1,47 @@
+package mini_orm
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registryBook struct {
+	ID   int64 `sql:"readOnly"`
+	Name string
+}
+
+func (b *registryBook) TableName() string {
+	return "registry_book"
+}
+
+func TestRegister(t *testing.T) {
+	assert.Equal(t, Register(&registryBook{}, registryBook{}), nil)
+	assert.True(t, errors.Is(Register(1), ModelNotSupportType))
+
+	var wg sync.WaitGroup
+	parsed := make([]*Model, 8)
+	for i := range parsed {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			parsed[i], _ = modelOf(reflect.TypeOf(registryBook{}))
+		}(i)
+	}
+	wg.Wait()
+	for _, m := range parsed {
+		assert.True(t, m == parsed[0])
+	}
+
+	a := &registryBook{ID: 1}
+	b := &registryBook{ID: 2}
+	ma, mb := NewModel(reflect.ValueOf(a)), NewModel(reflect.ValueOf(b))
+	assert.Equal(t, ma.TableName, "registry_book")
+	assert.Equal(t, ma.Value.Interface(), a)
+	assert.Equal(t, mb.Value.Interface(), b)
+	assert.True(t, ma.Fields["name"] == mb.Fields["name"])
+	assert.True(t, ma.Fields["id"].IsReadOnly)
+}
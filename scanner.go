@@ -2,16 +2,18 @@ package mini_orm
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
-	"strings"
+	"time"
 )
 
-const (
-	dbColumnName = "columnName"
-	dbPk         = "pk"
-	dbReadonly   = "readOnly"
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	sqlScannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 )
 
 // Scanner convert rows to entity
@@ -27,26 +29,59 @@ type Scanner struct {
 
 // Model describe table struct
 type Model struct {
-	TableName string
-	Value     reflect.Value
-	Fields    map[string]*Field
-	PkName    string
-	PkIdx     int
+	TableName     string
+	Value         reflect.Value
+	Fields        map[string]*Field
+	PkName        string
+	PkIdx         int // index of primary key or embedded struct holding it, use Fields[PkName]
+	PrimaryKeys   []*Field
+	SoftDelete    *Field
+	DefaultScopes []Scope
 }
 
-// Field describe table field
+// Field describe table field, options are parsed from `sql` tag, fields of
+// embedded struct are reached by index path like reflect.Value.FieldByIndex
 type Field struct {
-	Name         string
-	idx          int
-	Column       reflect.StructField
-	Tags         map[string]string
-	IsPrimaryKey bool
-	IsReadOnly   bool
+	Name           string
+	index          []int
+	Column         reflect.StructField
+	Tags           map[string]string
+	IsPrimaryKey   bool
+	IsReadOnly     bool
+	IsAutoIncr     bool
+	OmitEmpty      bool
+	Default        string
+	Type           string
+	Size           int
+	Index          bool
+	Unique         bool
+	NotNull        bool
+	AutoCreateTime bool
+	AutoUpdateTime bool
+	DeletedAt      bool
+	timeUnit       time.Duration
 }
 
-// NewModel return new model instanc
+// NewModel return model of value (pointer to struct), metadata is parsed once per
+// struct type and cached, the returned model is a copy with Value set
 func NewModel(value reflect.Value) *Model {
-	m := &Model{Value: value}
+	m, _ := newModel(value)
+	return m
+}
+
+// newModel like NewModel and return error of invalid model
+func newModel(value reflect.Value) (*Model, error) {
+	m, err := modelOf(reflect.Indirect(value).Type())
+	c := *m
+	c.Value = value
+	return &c, err
+}
+
+// parseModel parse metadata of struct type t, TableName and DefaultScopes are
+// called on zero value of t so they should not depend on field values
+func parseModel(t reflect.Type) (*Model, error) {
+	m := &Model{}
+	value := reflect.New(t)
 	_, ok := value.Type().MethodByName("TableName")
 	if ok {
 		vals := value.MethodByName("TableName").Call([]reflect.Value{})
@@ -57,41 +92,209 @@ func NewModel(value reflect.Value) *Model {
 			}
 		}
 	}
+	if scoper, ok := value.Interface().(DefaultScoper); ok {
+		m.DefaultScopes = scoper.DefaultScopes()
+	}
 	m.Fields = make(map[string]*Field)
-	elem := value.Elem()
-	for i := 0; i < elem.NumField(); i++ {
-		field := &Field{}
-		df := elem.Type().Field(i)
-		fieldName := ToSnakeCase(df.Name)
-		tags := make(map[string]string)
-		tag := strings.Split(df.Tag.Get("sql"), ",")
-		for _, t := range tag {
-			ts := strings.Split(t, "=")
-			if len(ts) == 1 {
-				if ts[0] == dbColumnName {
-					field.IsPrimaryKey = true
-				}
-				if ts[0] == dbReadonly {
-					field.IsReadOnly = true
+	fields := make([]*Field, 0, t.NumField())
+	if err := m.parseFields(t, t, nil, "", map[reflect.Type]bool{t: true}, &fields); err != nil {
+		return m, err
+	}
+	return m, m.addFields(t, fields)
+}
+
+// parseFields append fields of struct type t at index path to fields, fields of anonymous
+// struct and struct with `sql:"prefix=..."` are flattened, it returns the first error.
+// parents are struct types being parsed, a struct embedding itself returns TagError
+func (m *Model) parseFields(root, t reflect.Type, index []int, prefix string, parents map[reflect.Type]bool, fields *[]*Field) error {
+	var firstErr error
+	for i := 0; i < t.NumField(); i++ {
+		df := t.Field(i)
+		ft := df.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		embedded := df.Anonymous && isEmbeddable(ft)
+		if df.PkgPath != "" && (!embedded || df.Type.Kind() == reflect.Ptr) {
+			// unexported field, or unexported embedded pointer which can not be allocated
+			continue
+		}
+		path := append(append(make([]int, 0, len(index)+1), index...), i)
+		field := &Field{Name: ToSnakeCase(df.Name), index: path, Column: df, Tags: make(map[string]string)}
+		ignored, err := parseTag(root, df, field)
+		if err == nil && !ignored {
+			if p, ok := field.Tags[dbPrefix]; ok || embedded {
+				switch {
+				case !isEmbeddable(ft):
+					err = &TagError{Model: root.Name(), Field: df.Name, Tag: df.Tag.Get("sql"), Reason: "prefix expects struct field"}
+				case !ok && df.Tag.Get("sql") != "":
+					err = &TagError{Model: root.Name(), Field: df.Name, Tag: df.Tag.Get("sql"), Reason: "embedded struct only supports prefix or -"}
+				case parents[ft]:
+					err = &TagError{Model: root.Name(), Field: df.Name, Tag: df.Tag.Get("sql"), Reason: "recursive struct can not be flattened"}
+				default:
+					parents[ft] = true
+					err = m.parseFields(root, ft, path, prefix+p, parents, fields)
+					delete(parents, ft)
 				}
-			} else if len(ts) == 2 {
-				tags[ts[0]] = ts[1]
-				if ts[0] == dbColumnName {
-					fieldName = ts[1]
+			} else {
+				field.Name = prefix + field.Name
+				*fields = append(*fields, field)
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// isEmbeddable return true if struct type t is flattened into model, types
+// scanned as a single column e.g. time.Time or sql.NullString are not
+func isEmbeddable(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(sqlScannerType) && !t.Implements(valuerType)
+}
+
+// value return value of field in struct v, zero value if an embedded pointer is nil
+func (f *Field) value(v reflect.Value) reflect.Value {
+	for i, x := range f.index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Zero(f.Column.Type)
 				}
+				v = v.Elem()
 			}
 		}
-		field.Name = fieldName
-		field.idx = i
-		field.Column = df
-		field.Tags = tags
-		if field.IsPrimaryKey == true {
-			m.PkName = fieldName
-			m.PkIdx = i
+		v = v.Field(x)
+	}
+	return v
+}
+
+// settable return field of struct v for setting, nil embedded pointers are allocated
+func (f *Field) settable(v reflect.Value) reflect.Value {
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
 		}
-		m.Fields[fieldName] = field
+		v = v.Field(x)
 	}
-	return m
+	return v
+}
+
+// addFields add fields to model in order, like Go selectors a field shadows fields of
+// the same column nested deeper in embedded structs, fields at the same depth conflict
+func (m *Model) addFields(t reflect.Type, fields []*Field) error {
+	shallowest := make(map[string]*Field, len(fields))
+	for _, f := range fields {
+		if s, ok := shallowest[f.Name]; !ok || len(f.index) < len(s.index) {
+			shallowest[f.Name] = f
+		}
+	}
+	for _, f := range fields {
+		s := shallowest[f.Name]
+		if f == s {
+			if err := m.addField(t, f); err != nil {
+				return err
+			}
+		} else if len(f.index) == len(s.index) {
+			return &TagError{Model: t.Name(), Field: f.Column.Name, Tag: f.Column.Tag.Get("sql"),
+				Reason: fmt.Sprintf("column %s is also used by field %s", f.Name, s.Column.Name)}
+		}
+	}
+	return nil
+}
+
+// addField add field to model, column name should be unique
+func (m *Model) addField(t reflect.Type, field *Field) error {
+	if field.DeletedAt {
+		if m.SoftDelete != nil {
+			return &TagError{Model: t.Name(), Field: field.Column.Name, Tag: field.Column.Tag.Get("sql"),
+				Reason: fmt.Sprintf("deletedAt is already set by field %s", m.SoftDelete.Column.Name)}
+		}
+		m.SoftDelete = field
+	}
+	if field.IsPrimaryKey {
+		if m.PkName == "" {
+			m.PkName = field.Name
+			m.PkIdx = field.index[0]
+		}
+		m.PrimaryKeys = append(m.PrimaryKeys, field)
+	}
+	m.Fields[field.Name] = field
+	return nil
+}
+
+// pkColumns return column names of primary keys
+func (m *Model) pkColumns() []string {
+	columns := make([]string, 0, len(m.PrimaryKeys))
+	for _, f := range m.PrimaryKeys {
+		columns = append(columns, f.Name)
+	}
+	return columns
+}
+
+// pkValues return primary key values of struct v in order of PrimaryKeys
+func (m *Model) pkValues(v reflect.Value) []interface{} {
+	values := make([]interface{}, 0, len(m.PrimaryKeys))
+	for _, f := range m.PrimaryKeys {
+		values = append(values, f.value(v).Interface())
+	}
+	return values
+}
+
+// pkCondition return condition matching records by primary key values, keys are
+// values of single primary key or []interface{} in order of PrimaryKeys
+func (m *Model) pkCondition(keys [][]interface{}) interface{} {
+	if len(m.PrimaryKeys) == 1 {
+		values := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			values = append(values, k[0])
+		}
+		return Eq{m.PrimaryKeys[0].Name: values}
+	}
+	if len(keys) == 1 {
+		return m.pkEqual(keys[0])
+	}
+	return TupleIn{Columns: m.pkColumns(), Values: keys}
+}
+
+// pkEqual return condition of one record by primary key values e.g. id = ?, or
+// tenant_id = ? AND user_id = ? of composite primary keys
+func (m *Model) pkEqual(key []interface{}) interface{} {
+	if len(m.PrimaryKeys) == 1 {
+		return Eq{m.PrimaryKeys[0].Name: key[0]}
+	}
+	and := AND{}
+	for i, f := range m.PrimaryKeys {
+		and = append(and, Eq{f.Name: key[i]})
+	}
+	return and
+}
+
+// writableFields return fields not readOnly in order of struct fields
+func (m *Model) writableFields() []*Field {
+	fields := make([]*Field, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		if !f.IsReadOnly {
+			fields = append(fields, f)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
 }
 
 // NewScanner return new scanner instance
@@ -103,22 +306,26 @@ func NewScanner(dest interface{}) (*Scanner, error) {
 		entityPointer: reflect.Indirect(entityValue),
 	}
 
+	var err error
 	switch s.entityPointer.Kind() {
 	case reflect.Slice:
 		if s.entityPointer.Type().Elem().Kind() == reflect.Struct {
 			t := reflect.New(s.entityPointer.Type().Elem())
-			s.Model = NewModel(t)
+			s.Model, err = newModel(t)
 		} else if s.entityPointer.Type().Elem().Kind() == reflect.Ptr {
 			t := reflect.New(s.entityPointer.Type().Elem().Elem())
-			s.Model = NewModel(t)
+			s.Model, err = newModel(t)
 		} else {
 			return nil, ModelNotSupportType
 		}
 	case reflect.Struct:
-		s.Model = NewModel(s.entityValue)
+		s.Model, err = newModel(s.entityValue)
 	default:
 		return nil, ScannerEntiryTypeNotSupport
 	}
+	if err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -142,6 +349,23 @@ func (sc *Scanner) GetTableName() string {
 	return ""
 }
 
+// entities return struct values of dest, elements of slice are dereferenced,
+// ok is false if dest is neither slice nor struct
+func (sc *Scanner) entities() ([]reflect.Value, bool) {
+	switch sc.entityPointer.Kind() {
+	case reflect.Slice:
+		entities := make([]reflect.Value, 0, sc.entityPointer.Len())
+		for i := 0; i < sc.entityPointer.Len(); i++ {
+			entities = append(entities, reflect.Indirect(sc.entityPointer.Index(i)))
+		}
+		return entities, true
+	case reflect.Struct:
+		return []reflect.Value{sc.entityPointer}, true
+	default:
+		return nil, false
+	}
+}
+
 // Convert scan rows to dest
 func (sc *Scanner) Convert() error {
 	if sc.rows == nil {
@@ -178,7 +402,9 @@ func (sc *Scanner) convertAll() error {
 			return err
 		}
 		t := reflect.New(sc.entityPointer.Type().Elem().Elem())
-		sc.SetEntity(srcValue, t.Elem())
+		if err := sc.SetEntity(srcValue, t.Elem()); err != nil {
+			return err
+		}
 		dest = reflect.Append(dest, t)
 	}
 	sc.entityPointer.Set(dest)
@@ -214,7 +440,7 @@ func (sc *Scanner) SetEntity(srcValue []interface{}, dest reflect.Value) error {
 		if !ok {
 			continue
 		}
-		ff := dest.Field(field.idx)
+		ff := field.settable(dest)
 		rawVal := reflect.Indirect(reflect.ValueOf(val))
 		rawValInterface := rawVal.Interface()
 		if rawValInterface == nil {
@@ -434,6 +660,30 @@ func (sc *Scanner) SetEntity(srcValue []interface{}, dest reflect.Value) error {
 			default:
 				sc.defaultConvert(rawValInterface, &ff, field)
 			}
+		case reflect.Slice:
+			if ff.Type().Elem().Kind() == reflect.Uint8 {
+				sc.defaultConvert(rawValInterface, &ff, field)
+				continue
+			}
+			if scanner, ok := ff.Addr().Interface().(sql.Scanner); ok {
+				// slice type with its own encoding e.g. json, it is not postgres array
+				if err := scanner.Scan(rawValInterface); err != nil {
+					return fmt.Errorf("can not convert field:%s to %s err:%v", name, ff.Type(), err)
+				}
+				continue
+			}
+			switch d := rawValInterface.(type) {
+			case []byte:
+				if err := setArray(ff, string(d)); err != nil {
+					return fmt.Errorf("can not convert field:%s to %s err:%v", name, ff.Type(), err)
+				}
+			case string:
+				if err := setArray(ff, d); err != nil {
+					return fmt.Errorf("can not convert field:%s to %s err:%v", name, ff.Type(), err)
+				}
+			default:
+				sc.defaultConvert(rawValInterface, &ff, field)
+			}
 		default:
 			sc.defaultConvert(rawValInterface, &ff, field)
 		}
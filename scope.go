@@ -0,0 +1,47 @@
+package mini_orm
+
+// Scope reusable query fragment
+// e.g. func(st *Statement) *Statement { return st.Where(Eq{"status": "active"}) }
+type Scope func(*Statement) *Statement
+
+// DefaultScoper model declare scopes applied on every query of it unless Unscoped is called,
+// it is discovered by NewModel on pointer to model
+type DefaultScoper interface {
+	DefaultScopes() []Scope
+}
+
+// Scopes add scopes applied to statement before FindOne, FindAll, Count, Paginate and Explain
+func (s *Session) Scopes(scopes ...Scope) *Session {
+	s.scopes = append(s.scopes, scopes...)
+	return s
+}
+
+// Unscoped disable default scopes and soft delete filter of model for next query,
+// the filter applies to the base statement, not to its set operations and CTEs
+func (s *Session) Unscoped() *Session {
+	s.unscoped = true
+	return s
+}
+
+// applyScopes apply default scopes of model and scopes of session once per query
+func (s *Session) applyScopes(m *Model) {
+	if s.scoped {
+		return
+	}
+	s.scoped = true
+	scopes := make([]Scope, 0, len(s.scopes))
+	if m != nil && !s.unscoped {
+		if m.SoftDelete != nil {
+			scopes = append(scopes, func(st *Statement) *Statement {
+				return st.Where(m.notDeleted(st))
+			})
+		}
+		scopes = append(scopes, m.DefaultScopes...)
+	}
+	scopes = append(scopes, s.scopes...)
+	for _, scope := range scopes {
+		if st := scope(s.statement); st != nil {
+			s.statement = st
+		}
+	}
+}
@@ -3,7 +3,10 @@ package mini_orm
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
+
+	sq "github.com/Masterminds/squirrel"
 )
 
 // Session db conn session
@@ -16,6 +19,16 @@ type Session struct {
 	isAutoCommit           bool
 	hasCommittedOrRollback bool
 	tx                     *sql.Tx
+	cursor                 *cursorQuery
+	scopes                 []Scope
+	scoped                 bool
+	unscoped               bool
+	model                  *Model
+	skipValidation         bool
+	atomic                 bool
+	chunkSize              int
+	dryRun                 *dryRunRecorder
+	hardDelete             bool
 }
 
 // UseMaster enable use master
@@ -27,7 +40,7 @@ func (s *Session) UseMaster() *Session {
 // FindOne get one result
 func (s *Session) FindOne(dest interface{}) error {
 	s.initStatemnt()
-	s.Limit(1)
+	defer s.resetStatement()
 	scanner, err := NewScanner(dest)
 	if err != nil {
 		return err
@@ -39,11 +52,19 @@ func (s *Session) FindOne(dest interface{}) error {
 	if s.statement.table == "" {
 		s.statement.From(scanner.GetTableName())
 	}
-	sql, args, err := s.statement.ToSQL()
+	if err := s.prepareQuery(scanner.Model); err != nil {
+		return err
+	}
+	// limit is set after scopes so a scope with Limit can not override it
+	s.Limit(1)
+	sql, args, err := s.toSQL()
 	if err != nil {
 		return err
 	}
 	Tracef("[Session FindOne] sql: %s, args: %v", sql, args)
+	if s.recordDryRun(sql, args) {
+		return nil
+	}
 	s.initCtx()
 	rows, err := s.QueryContext(s.ctx, sql, args...)
 	if err != nil {
@@ -56,6 +77,7 @@ func (s *Session) FindOne(dest interface{}) error {
 // FindAll get all result
 func (s *Session) FindAll(dest interface{}) error {
 	s.initStatemnt()
+	defer s.resetStatement()
 	scanner, err := NewScanner(dest)
 	if err != nil {
 		return err
@@ -67,11 +89,17 @@ func (s *Session) FindAll(dest interface{}) error {
 	if s.statement.table == "" {
 		s.statement.From(scanner.GetTableName())
 	}
-	sql, args, err := s.statement.ToSQL()
+	if err := s.prepareQuery(scanner.Model); err != nil {
+		return err
+	}
+	sql, args, err := s.toSQL()
 	if err != nil {
 		return err
 	}
 	Tracef("[Session FindAll] sql: %s, args: %v", sql, args)
+	if s.recordDryRun(sql, args) {
+		return nil
+	}
 	s.initCtx()
 	rows, err := s.QueryContext(s.ctx, sql, args...)
 	if err != nil {
@@ -81,9 +109,12 @@ func (s *Session) FindAll(dest interface{}) error {
 	return scanner.Convert()
 }
 
-// Insert create new record
+// Insert create new records, records of slice are inserted by multi-row VALUES
+// split into chunks by bind parameters limit of dialect, for dialect without DEFAULT
+// in VALUES records of a chunk are further split by omitempty and autoincr fields left zero
 func (s *Session) Insert(dest interface{}) (int64, error) {
 	s.initStatemnt()
+	defer s.resetStatement()
 	s.statement.Insert()
 	scanner, err := NewScanner(dest)
 	if err != nil {
@@ -93,195 +124,357 @@ func (s *Session) Insert(dest interface{}) (int64, error) {
 	if s.statement.table == "" {
 		s.statement.From(scanner.GetTableName())
 	}
-	insertFields := make([]string, 0)
-	for n, f := range scanner.Model.Fields {
-		if !f.IsReadOnly {
-			insertFields = append(insertFields, n)
-		}
+	fields := scanner.Model.writableFields()
+	entities, ok := scanner.entities()
+	if !ok {
+		return 0, InsertExpectSliceOrStruct
 	}
-	s.Columns(insertFields...)
-	if scanner.entityPointer.Kind() == reflect.Slice {
-		for i := 0; i < scanner.entityPointer.Len(); i++ {
-			val := make([]interface{}, 0)
-			sub := scanner.entityPointer.Index(i)
-			if sub.Kind() == reflect.Ptr {
-				subElem := sub.Elem()
-				for _, fn := range insertFields {
-					f, ok := scanner.Model.Fields[fn]
-					if !ok {
-						continue
-					}
-					fv := subElem.Field(f.idx)
-					val = append(val, fv.Interface())
-				}
-
-			} else {
-				for _, fn := range insertFields {
-					f, ok := scanner.Model.Fields[fn]
-					if !ok {
-						continue
-					}
-					fv := sub.Field(f.idx)
-					val = append(val, fv.Interface())
+	entities, restore := s.setTimestamps(scanner.Model, entities, true)
+	size := s.maxParams()
+	if len(fields) > 0 {
+		size /= len(fields)
+	}
+	statements := make([]*Statement, 0)
+	chunks := chunkValues(entities, size)
+	if !s.db.Dialect().valuesDefault() {
+		chunks = defaultGroups(fields, chunks)
+	}
+	for _, chunk := range chunks {
+		columns := insertColumns(fields, chunk)
+		names := make([]string, 0, len(columns))
+		for _, f := range columns {
+			names = append(names, f.Name)
+		}
+		st := s.statement.Clone().Columns(names...)
+		for _, entity := range chunk {
+			val := make([]interface{}, 0, len(columns))
+			for _, f := range columns {
+				fv := f.value(entity)
+				if (f.OmitEmpty || f.IsAutoIncr) && fv.IsZero() {
+					val = append(val, sq.Expr("DEFAULT"))
+				} else if f.DeletedAt && fv.IsZero() {
+					val = append(val, f.notDeletedValue())
+				} else {
+					val = append(val, s.argValue(fv))
 				}
 			}
-			s.statement.Values(val)
+			st.Values(val)
 		}
+		statements = append(statements, st)
+	}
+	affected, err := s.execChunks("Insert", statements)
+	if err != nil || s.dryRun != nil {
+		restore()
+	}
+	return affected, err
+}
 
-	} else if scanner.entityPointer.Kind() == reflect.Struct {
-		val := make([]interface{}, 0)
-		for _, fn := range insertFields {
-			f, ok := scanner.Model.Fields[fn]
-			if !ok {
+// InsertFrom insert records selected by source into table e.g.
+// InsertFrom("codebook_archive", []string{"id", "name"}, (&Statement{}).Select("id", "name").From("codebook"))
+func (s *Session) InsertFrom(table string, columns []string, source *Statement) (int64, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	s.statement.Insert().From(table).Columns(columns...).InsertSelect(source)
+	return s.execStatements("InsertFrom", []*Statement{s.statement})
+}
+
+// Update update records by primary key, each record is updated by its own statement
+func (s *Session) Update(dest interface{}) (int64, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	s.statement.Update()
+	m, entities, err := s.pkTarget(dest, UpdateExpectSliceOrStruct)
+	if err != nil {
+		return 0, err
+	}
+	entities, restore := s.setTimestamps(m, entities, false)
+	fields := m.writableFields()
+	statements := make([]*Statement, 0, len(entities))
+	for _, entity := range entities {
+		names := make([]string, 0, len(fields))
+		val := make([]interface{}, 0, len(fields))
+		for _, f := range fields {
+			fv := f.value(entity)
+			if f.IsPrimaryKey || f.IsAutoIncr || f.AutoCreateTime || f.DeletedAt || (f.OmitEmpty && fv.IsZero()) {
 				continue
 			}
-			fv := scanner.entityPointer.Field(f.idx)
-			val = append(val, fv.Interface())
+			names = append(names, f.Name)
+			val = append(val, s.argValue(fv))
 		}
-		s.statement.Values(val)
-	} else {
-		return 0, InsertExpectSliceOrStruct
+		pk := m.pkEqual(m.pkValues(entity))
+		statements = append(statements, s.statement.Clone().Columns(names...).Values(val).Where(pk))
 	}
-	sql, args, err := s.statement.ToSQL()
-	if err != nil {
-		return 0, err
+	affected, err := s.execChunks("Update", statements)
+	if err != nil || s.dryRun != nil {
+		restore()
 	}
-	Tracef("[Session Insert] sql: %s, args: %v", sql, args)
-	s.initCtx()
-	sResult, err := s.ExecContext(s.ctx, sql, args...)
+	return affected, err
+}
+
+// Delete delete records by primary key, primary keys of slice are split into
+// chunks by bind parameters limit of dialect, composite primary keys are matched
+// by (a, b) IN ((?, ?), ...) for postgres and mysql, OR of AND for others.
+// records of model with deletedAt field are soft deleted by UPDATE, see HardDelete,
+// deletedAt field of records is set only if every record is matched by the UPDATE
+func (s *Session) Delete(dest interface{}) (int64, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	s.statement.Delete()
+	m, entities, err := s.pkTarget(dest, DeleteExpectSliceOrStruct)
 	if err != nil {
 		return 0, err
 	}
-	return sResult.RowsAffected()
+	if m.SoftDelete == nil || s.hardDelete {
+		return s.execPkStatements("Delete", m, entities, 0)
+	}
+	now := s.db.now()
+	s.statement.stType = UpdateStatement
+	s.statement.Columns(m.SoftDelete.Name).Values([]interface{}{m.SoftDelete.deletedValue(now)})
+	if !s.unscoped {
+		s.statement.Where(m.notDeleted(s.statement))
+	}
+	affected, err := s.execPkStatements("Delete", m, entities, 1)
+	if err == nil && affected == int64(len(entities)) {
+		m.SoftDelete.setDeletedAt(entities, func(fv reflect.Value) {
+			setTime(fv, m.SoftDelete.timeUnit, now)
+		})
+	}
+	return affected, err
 }
 
-// Update update one record
-func (s *Session) Update(dest interface{}) (int64, error) {
-	s.initStatemnt()
-	s.statement.Update()
+// pkTarget return model and records of dest for statement matching records by
+// primary key, table of statement is set to table of model if not set
+func (s *Session) pkTarget(dest interface{}, kindErr error) (*Model, []reflect.Value, error) {
 	scanner, err := NewScanner(dest)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 	defer scanner.Close()
 	if s.statement.table == "" {
 		s.statement.From(scanner.GetTableName())
 	}
-	updateFields := make([]string, 0)
-	pks := make([]interface{}, 0)
-	for n, f := range scanner.Model.Fields {
-		if !f.IsReadOnly {
-			updateFields = append(updateFields, n)
-		}
+	if len(scanner.Model.PrimaryKeys) == 0 {
+		return nil, nil, ModelMissingPrimaryKey
 	}
-	s.Columns(updateFields...)
-	if scanner.entityPointer.Kind() == reflect.Slice {
-		for i := 0; i < scanner.entityPointer.Len(); i++ {
-			val := make([]interface{}, 0)
-			sub := scanner.entityPointer.Index(i)
-			if sub.Kind() == reflect.Ptr {
-				subElem := sub.Elem()
-				for _, fn := range updateFields {
-					f, ok := scanner.Model.Fields[fn]
-					if !ok {
-						continue
-					}
-					fv := subElem.Field(f.idx)
-					if f.IsPrimaryKey {
-						pks = append(pks, fv.Interface())
-					}
-					val = append(val, fv.Interface())
-				}
-
-			} else {
-				for _, fn := range updateFields {
-					f, ok := scanner.Model.Fields[fn]
-					if !ok {
-						continue
-					}
-					fv := sub.Field(f.idx)
-					if f.IsPrimaryKey {
-						pks = append(pks, fv.Interface())
-					}
-					val = append(val, fv.Interface())
-				}
-			}
-			s.statement.Values(val)
-		}
-
-	} else if scanner.entityPointer.Kind() == reflect.Struct {
-		val := make([]interface{}, 0)
-		for _, fn := range updateFields {
-			f, ok := scanner.Model.Fields[fn]
-			if !ok {
-				continue
-			}
-			fv := scanner.entityPointer.Field(f.idx)
-			if f.IsPrimaryKey {
-				pks = append(pks, fv.Interface())
-			}
-			val = append(val, fv.Interface())
-		}
-		s.statement.Values(val)
-	} else {
-		return 0, UpdateExpectSliceOrStruct
+	entities, ok := scanner.entities()
+	if !ok {
+		return nil, nil, kindErr
 	}
-	s.Where(Eq{scanner.Model.PkName: pks})
-	sql, args, err := s.statement.ToSQL()
-	if err != nil {
-		return 0, err
+	if err := checkPrimaryKeys(scanner.Model, entities); err != nil {
+		return nil, nil, err
 	}
-	Tracef("[Session Update] sql: %s, args: %v", sql, args)
-	s.initCtx()
-	sResult, err := s.ExecContext(s.ctx, sql, args...)
+	if err := s.validate(scanner.Model); err != nil {
+		return nil, nil, err
+	}
+	return scanner.Model, entities, nil
+}
+
+// execPkStatements execute statement for primary keys of entities, keys are split
+// into chunks by bind parameters limit, extra is count of parameters not in conditions
+func (s *Session) execPkStatements(name string, m *Model, entities []reflect.Value, extra int) (int64, error) {
+	used, err := s.statement.conditionArgs()
 	if err != nil {
 		return 0, err
 	}
-	return sResult.RowsAffected()
+	statements := make([]*Statement, 0)
+	size := (s.maxParams() - used - extra) / len(m.PrimaryKeys)
+	for _, chunk := range chunkValues(entities, size) {
+		keys := make([][]interface{}, 0, len(chunk))
+		for _, entity := range chunk {
+			keys = append(keys, m.pkValues(entity))
+		}
+		statements = append(statements, s.statement.Clone().Where(m.pkCondition(keys)))
+	}
+	return s.execChunks(name, statements)
 }
 
-// Delete delete one record
-func (s *Session) Delete(dest interface{}) (int64, error) {
+// FindByKey get one record by primary key, values are in order of primary keys
+// e.g. FindByKey(&membership, tenantID, userID)
+func (s *Session) FindByKey(dest interface{}, key ...interface{}) error {
+	return s.whereKeys(dest, [][]interface{}{key}).FindOne(dest)
+}
+
+// FindAllByKeys get records by primary keys, key is value of single primary key or
+// []interface{} of composite primary keys e.g. FindAllByKeys(&memberships, []interface{}{1, 2}, []interface{}{1, 3})
+func (s *Session) FindAllByKeys(dest interface{}, keys ...interface{}) error {
+	values := make([][]interface{}, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := k.([]interface{}); ok {
+			values = append(values, v)
+		} else {
+			values = append(values, []interface{}{k})
+		}
+	}
+	return s.whereKeys(dest, values).FindAll(dest)
+}
+
+// whereKeys add primary keys condition of model of dest to select statement, it
+// selects "*" if statement is not set, error is returned by query
+func (s *Session) whereKeys(dest interface{}, keys [][]interface{}) *Session {
 	s.initStatemnt()
-	s.statement.Delete()
+	if s.statement.stType == UnknownStatement {
+		s.statement.Select()
+	}
 	scanner, err := NewScanner(dest)
 	if err != nil {
-		return 0, err
+		s.e = err
+		return s
 	}
-	defer scanner.Close()
-	if s.statement.table == "" {
-		s.statement.From(scanner.GetTableName())
+	if len(scanner.Model.PrimaryKeys) == 0 {
+		s.e = ModelMissingPrimaryKey
+		return s
+	}
+	for _, k := range keys {
+		if len(k) != len(scanner.Model.PrimaryKeys) {
+			s.e = fmt.Errorf("%w: got %d values of primary keys %v", PrimaryKeyMismatch, len(k), scanner.Model.pkColumns())
+			return s
+		}
 	}
-	pks := make([]interface{}, 0)
-	if scanner.Model.PkName == "" {
-		return 0, ModelMissingPrimaryKey
-	}
-	if scanner.entityPointer.Kind() == reflect.Slice {
-		for i := 0; i < scanner.entityPointer.Len(); i++ {
-			sub := scanner.entityPointer.Index(i)
-			if sub.Kind() == reflect.Ptr {
-				pks = append(pks, sub.Elem().Field(scanner.Model.PkIdx).Interface())
-			} else {
-				pks = append(pks, sub.Field(scanner.Model.PkIdx).Interface())
+	return s.Where(scanner.Model.pkCondition(keys))
+}
+
+// insertColumns return fields inserted by chunk, omitempty and autoincr fields are
+// left out if they are zero value in every record, so database default is used
+func insertColumns(fields []*Field, chunk []reflect.Value) []*Field {
+	columns := make([]*Field, 0, len(fields))
+	for _, f := range fields {
+		if !f.OmitEmpty && !f.IsAutoIncr {
+			columns = append(columns, f)
+			continue
+		}
+		for _, entity := range chunk {
+			if !f.value(entity).IsZero() {
+				columns = append(columns, f)
+				break
 			}
 		}
-	} else if scanner.entityPointer.Kind() == reflect.Struct {
-		pks = append(pks, scanner.entityPointer.Field(scanner.Model.PkIdx).Interface())
-	} else {
-		return 0, DeleteExpectSliceOrStruct
 	}
-	s.Where(Eq{scanner.Model.PkName: pks})
-	sql, args, err := s.statement.ToSQL()
-	if err != nil {
+	return columns
+}
+
+// defaultGroups split each chunk into groups of records having the same omitempty and
+// autoincr fields of zero value, so insertColumns leaves them out and no DEFAULT is needed,
+// groups keep order of their first record
+func defaultGroups(fields []*Field, chunks [][]reflect.Value) [][]reflect.Value {
+	groups := make([][]reflect.Value, 0, len(chunks))
+	for _, chunk := range chunks {
+		index := make(map[string]int)
+		for _, entity := range chunk {
+			key := make([]byte, 0, len(fields))
+			for _, f := range fields {
+				if (f.OmitEmpty || f.IsAutoIncr) && f.value(entity).IsZero() {
+					key = append(key, '0')
+				} else {
+					key = append(key, '1')
+				}
+			}
+			i, ok := index[string(key)]
+			if !ok {
+				i = len(groups)
+				index[string(key)] = i
+				groups = append(groups, make([]reflect.Value, 0, 1))
+			}
+			groups[i] = append(groups[i], entity)
+		}
+	}
+	return groups
+}
+
+// checkPrimaryKeys return error if entities is empty or any primary key is zero value,
+// so a bug of caller never turns into update or delete without effective filter
+func checkPrimaryKeys(m *Model, entities []reflect.Value) error {
+	if len(entities) == 0 {
+		return EmptyRecords
+	}
+	for _, entity := range entities {
+		for _, f := range m.PrimaryKeys {
+			if f.value(entity).IsZero() {
+				return ZeroPrimaryKey
+			}
+		}
+	}
+	return nil
+}
+
+// ChunkSize set max bind parameters of one statement, Insert and Delete of
+// slice are split into chunks by it, default is limit of dialect
+func (s *Session) ChunkSize(n int) *Session {
+	s.chunkSize = n
+	return s
+}
+
+// Atomic execute chunks of next Insert, Update or Delete in one transaction,
+// it has no effect in transaction already began
+func (s *Session) Atomic() *Session {
+	s.atomic = true
+	return s
+}
+
+// maxParams return max bind parameters of one statement
+func (s *Session) maxParams() int {
+	if s.chunkSize > 0 {
+		return s.chunkSize
+	}
+	return s.db.Dialect().maxParams()
+}
+
+// execChunks execute statements and sum affected rows, statements are executed
+// in transaction if Atomic is set and session not in transaction
+func (s *Session) execChunks(name string, statements []*Statement) (int64, error) {
+	if !s.atomic || s.tx != nil || s.dryRun != nil || len(statements) < 2 {
+		return s.execStatements(name, statements)
+	}
+	if err := s.Begin(); err != nil {
 		return 0, err
 	}
-	Tracef("[Session Delete] sql: %s, args: %v", sql, args)
-	s.initCtx()
-	sResult, err := s.ExecContext(s.ctx, sql, args...)
+	defer func() {
+		s.tx = nil
+		s.isAutoCommit = true
+	}()
+	affected, err := s.execStatements(name, statements)
 	if err != nil {
+		if rbErr := s.RollBack(); rbErr != nil {
+			Warnf("[Session %s] rollback failed: %v", name, rbErr)
+		}
 		return 0, err
 	}
-	return sResult.RowsAffected()
+	return affected, s.Commit()
+}
+
+// execStatements execute statements one by one and sum affected rows
+func (s *Session) execStatements(name string, statements []*Statement) (int64, error) {
+	var total int64
+	for _, st := range statements {
+		sql, args, err := s.statementSQL(st)
+		if err != nil {
+			return total, err
+		}
+		Tracef("[Session %s] sql: %s, args: %v", name, sql, args)
+		if s.recordDryRun(sql, args) {
+			continue
+		}
+		s.initCtx()
+		sResult, err := s.ExecContext(s.ctx, sql, args...)
+		if err != nil {
+			return total, err
+		}
+		affected, err := sResult.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+	}
+	return total, nil
+}
+
+// argValue return field value as sql argument, slices are postgres arrays unless
+// slice type implements driver.Valuer with its own encoding
+func (s *Session) argValue(fv reflect.Value) interface{} {
+	if s.db.Dialect() == PostgresDialect && fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 &&
+		!fv.Type().Implements(valuerType) {
+		return Array(fv.Interface())
+	}
+	return fv.Interface()
 }
 
 func (s *Session) initCtx() {
@@ -290,23 +483,36 @@ func (s *Session) initCtx() {
 	}
 }
 
-// Count return query count
+// Count return query count, soft deleted rows are skipped by model of Model or by
+// registered model of table if Model is not called
 func (s *Session) Count() (int64, error) {
 	s.initStatemnt()
-	s.Columns("count(*)")
-	sql, args, err := s.statement.ToSQL()
+	defer s.resetStatement()
+	if s.model != nil && s.statement.table == "" {
+		s.statement.From(s.model.TableName)
+	}
+	if err := s.prepareQuery(s.model); err != nil {
+		return 0, err
+	}
+	if m := softDeleteOf(s.statement.table); s.model == nil && m != nil && !s.unscoped {
+		s.statement.Where(m.notDeleted(s.statement))
+	}
+	s.statement = s.statement.countStatement()
+	sql, args, err := s.toSQL()
 	if err != nil {
 		return 0, err
 	}
 	Tracef("[Session Count] sql: %s, args: %v", sql, args)
+	if s.recordDryRun(sql, args) {
+		return 0, nil
+	}
 	var count int64
 	s.initCtx()
-	rows, err := s.QueryContext(s.ctx, sql, args...)
+	row, err := s.queryRowContext(s.ctx, sql, args...)
 	if err != nil {
 		return 0, err
 	}
-	rows.Next()
-	err = rows.Scan(&count)
+	err = row.Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -319,6 +525,105 @@ func (s *Session) initStatemnt() {
 	}
 }
 
+// Model set target model of query e.g. Model(&CodeBook{}), it is used by Count
+// to get table, default scopes and validate columns
+func (s *Session) Model(value interface{}) *Session {
+	scanner, err := NewScanner(value)
+	if err != nil {
+		s.e = err
+		return s
+	}
+	s.model = scanner.Model
+	return s
+}
+
+// SkipValidation disable validation of columns against model for next query
+func (s *Session) SkipValidation() *Session {
+	s.skipValidation = true
+	return s
+}
+
+// prepareQuery apply scopes and validate columns with model before query
+func (s *Session) prepareQuery(m *Model) error {
+	if s.e != nil {
+		return s.e
+	}
+	s.applyScopes(m)
+	return s.validate(m)
+}
+
+// validate check columns used in Where, Columns and OrderBy against model,
+// model is not used to validate joined and combined queries
+func (s *Session) validate(m *Model) error {
+	st := s.statement
+	if len(st.joins) > 0 || len(st.ctes) > 0 || len(st.setOps) > 0 {
+		m = nil
+	}
+	if m != nil && !s.skipValidation {
+		if err := st.validateColumns(m); err != nil {
+			return err
+		}
+	}
+	return st.validateOrderBy(m)
+}
+
+// toSQL gen statement SQL with dialect of session db
+func (s *Session) toSQL() (string, []interface{}, error) {
+	return s.statementSQL(s.statement)
+}
+
+// statementSQL gen SQL of st with dialect of session db, comment tags of ctx
+// are added unless st has the same key
+func (s *Session) statementSQL(st *Statement) (string, []interface{}, error) {
+	st.SetDialect(s.db.Dialect())
+	for k, v := range SQLCommentsFrom(s.ctx) {
+		if _, ok := st.comments[k]; !ok {
+			st.Comment(k, v)
+		}
+	}
+	return st.ToSQL()
+}
+
+// ToSQLString return SQL of current statement with arguments interpolated, it does
+// not execute or reset the statement and is for debugging and logs only
+func (s *Session) ToSQLString() (string, error) {
+	s.initStatemnt()
+	st := s.statement.Clone()
+	query, args, err := s.statementSQL(st)
+	if err != nil {
+		return "", err
+	}
+	return interpolate(st.dialect, query, args)
+}
+
+// resetStatement reset statement after query executed, so conditions of
+// previous query not stack on next query with the same session
+func (s *Session) resetStatement() {
+	s.statement.Reset()
+	s.cursor = nil
+	s.scopes = nil
+	s.scoped = false
+	s.unscoped = false
+	s.model = nil
+	s.skipValidation = false
+	s.atomic = false
+	s.hardDelete = false
+	s.e = nil
+}
+
+// Clone return new session with copy of statement, it shares db, ctx and transaction,
+// so a base query can be built once and branched with base.Clone().Where(...)
+func (s *Session) Clone() *Session {
+	c := *s
+	c.statement = s.statement.Clone()
+	c.scopes = append([]Scope(nil), s.scopes...)
+	if s.cursor != nil {
+		cursor := *s.cursor
+		c.cursor = &cursor
+	}
+	return &c
+}
+
 // Select select columns default "*"
 func (s *Session) Select(columns ...string) *Session {
 	s.initStatemnt()
@@ -333,6 +638,42 @@ func (s *Session) Columns(columns ...string) *Session {
 	return s
 }
 
+// Comment add key/value tag rendered as sqlcommenter comment, tags of ctx set by
+// WithSQLComment are added to every query automatically
+func (s *Session) Comment(key, value string) *Session {
+	s.initStatemnt()
+	s.statement.Comment(key, value)
+	return s
+}
+
+// Hint add optimizer hints
+func (s *Session) Hint(hints ...string) *Session {
+	s.initStatemnt()
+	s.statement.Hint(hints...)
+	return s
+}
+
+// UseIndex hint indexes of table
+func (s *Session) UseIndex(indexes ...string) *Session {
+	s.initStatemnt()
+	s.statement.UseIndex(indexes...)
+	return s
+}
+
+// Window add window function columns
+func (s *Session) Window(exprs ...*WindowExpr) *Session {
+	s.initStatemnt()
+	s.statement.Window(exprs...)
+	return s
+}
+
+// JSONSelect add text of json path as column
+func (s *Session) JSONSelect(path, alias string) *Session {
+	s.initStatemnt()
+	s.statement.JSONSelect(path, alias)
+	return s
+}
+
 // From set select table
 func (s *Session) From(table string) *Session {
 	s.initStatemnt()
@@ -347,6 +688,41 @@ func (s *Session) Where(expr ...interface{}) *Session {
 	return s
 }
 
+// With declare common table expression
+func (s *Session) With(name string, sub *Statement) *Session {
+	s.initStatemnt()
+	s.statement.With(name, sub)
+	return s
+}
+
+// WithRecursive declare recursive common table expression
+func (s *Session) WithRecursive(name string, sub *Statement) *Session {
+	s.initStatemnt()
+	s.statement.WithRecursive(name, sub)
+	return s
+}
+
+// Join add JOIN clause
+func (s *Session) Join(join string, args ...interface{}) *Session {
+	s.initStatemnt()
+	s.statement.Join(join, args...)
+	return s
+}
+
+// LeftJoin add LEFT JOIN clause
+func (s *Session) LeftJoin(join string, args ...interface{}) *Session {
+	s.initStatemnt()
+	s.statement.LeftJoin(join, args...)
+	return s
+}
+
+// RightJoin add RIGHT JOIN clause
+func (s *Session) RightJoin(join string, args ...interface{}) *Session {
+	s.initStatemnt()
+	s.statement.RightJoin(join, args...)
+	return s
+}
+
 // Limit set limit
 func (s *Session) Limit(limit uint64) *Session {
 	s.statement.Limit(limit)
@@ -359,9 +735,53 @@ func (s *Session) Offset(offset uint64) *Session {
 	return s
 }
 
-// OrderBy set order by
-func (s *Session) OrderBy(orderby string) *Session {
-	s.statement.OrderBy(orderby)
+// OrderBy add order by column e.g. OrderBy("created_at", Desc, NullsLast),
+// column must be field of model or in AllowOrderBy whitelist, see Statement.OrderBy
+func (s *Session) OrderBy(column string, direction OrderOption, nulls ...OrderOption) *Session {
+	s.initStatemnt()
+	s.statement.OrderBy(column, direction, nulls...)
+	return s
+}
+
+// OrderByRaw add raw order by e.g. OrderByRaw("id desc"), never use it with user input
+func (s *Session) OrderByRaw(orderby string) *Session {
+	s.initStatemnt()
+	s.statement.OrderByRaw(orderby)
+	return s
+}
+
+// AllowOrderBy set whitelist of OrderBy columns instead of model fields
+func (s *Session) AllowOrderBy(columns ...string) *Session {
+	s.initStatemnt()
+	s.statement.AllowOrderBy(columns...)
+	return s
+}
+
+// Union combine query with others by UNION
+func (s *Session) Union(others ...*Statement) *Session {
+	s.initStatemnt()
+	s.statement.Union(others...)
+	return s
+}
+
+// UnionAll combine query with others by UNION ALL
+func (s *Session) UnionAll(others ...*Statement) *Session {
+	s.initStatemnt()
+	s.statement.UnionAll(others...)
+	return s
+}
+
+// Intersect combine query with others by INTERSECT
+func (s *Session) Intersect(others ...*Statement) *Session {
+	s.initStatemnt()
+	s.statement.Intersect(others...)
+	return s
+}
+
+// Except combine query with others by EXCEPT
+func (s *Session) Except(others ...*Statement) *Session {
+	s.initStatemnt()
+	s.statement.Except(others...)
 	return s
 }
 
@@ -387,8 +807,42 @@ func (s *Session) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	return s.db.Slave().Query(query, args...)
 }
 
-// QueryContext use QueryContext with session config
+// prepared return cached prepared statement on the node query would run and its
+// release func to call after the statement is executed, nil if statement cache is disabled
+// or ctx has sql comment tags, they are request level so the SQL would be prepared per request
+func (s *Session) prepared(ctx context.Context, query string, useMaster bool) (*sql.Stmt, func(), error) {
+	if s.db.stmtCache == nil || len(SQLCommentsFrom(ctx)) > 0 {
+		return nil, func() {}, nil
+	}
+	if s.tx != nil {
+		if stmt, release, ok := s.db.stmtCache.lookup("master", query); ok {
+			return s.tx.StmtContext(ctx, stmt), release, nil
+		}
+		// preparing on master would wait for a second connection while tx holds one,
+		// statement prepared by tx is closed by database/sql when tx ends
+		stmt, err := s.tx.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stmt, func() {}, nil
+	}
+	node, db := "master", s.db.Master()
+	if !useMaster {
+		node, db = s.db.slaveNode()
+	}
+	return s.db.stmtCache.get(ctx, node, db, query)
+}
+
+// QueryContext use QueryContext with session config, prepared statement is used if cache enabled
 func (s *Session) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, release, err := s.prepared(ctx, query, s.useMaster)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
 	if s.tx != nil {
 		return s.tx.QueryContext(ctx, query, args...)
 	}
@@ -398,7 +852,8 @@ func (s *Session) QueryContext(ctx context.Context, query string, args ...interf
 	return s.db.Slave().QueryContext(ctx, query, args...)
 }
 
-// QueryRawContext use QueryRawContext with session config
+// QueryRawContext use QueryRawContext with session config, it is never prepared as
+// *sql.Row can not return error of prepare
 func (s *Session) QueryRawContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	if s.tx != nil {
 		return s.tx.QueryRowContext(ctx, query, args...)
@@ -409,6 +864,20 @@ func (s *Session) QueryRawContext(ctx context.Context, query string, args ...int
 	return s.db.Slave().QueryRowContext(ctx, query, args...)
 }
 
+// queryRowContext use QueryRowContext with session config, prepared statement is
+// used if cache enabled and error of prepare is returned like QueryContext
+func (s *Session) queryRowContext(ctx context.Context, query string, args ...interface{}) (*sql.Row, error) {
+	stmt, release, err := s.prepared(ctx, query, s.useMaster)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if stmt != nil {
+		return stmt.QueryRowContext(ctx, args...), nil
+	}
+	return s.QueryRawContext(ctx, query, args...), nil
+}
+
 // Exec execute
 func (s *Session) Exec(query string, args ...interface{}) (sql.Result, error) {
 	if s.tx != nil {
@@ -417,8 +886,16 @@ func (s *Session) Exec(query string, args ...interface{}) (sql.Result, error) {
 	return s.db.Master().Exec(query, args...)
 }
 
-// ExecContext execute with context
+// ExecContext execute with context, prepared statement is used if cache enabled
 func (s *Session) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, release, err := s.prepared(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
 	if s.tx != nil {
 		return s.tx.ExecContext(ctx, query, args...)
 	}
@@ -0,0 +1,88 @@
+package mini_orm
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// HardDelete delete records by primary key even if model has deletedAt field
+func (s *Session) HardDelete(dest interface{}) (int64, error) {
+	s.hardDelete = true
+	return s.Delete(dest)
+}
+
+// Restore clear deletedAt field of soft deleted records by primary key, deletedAt
+// field of records is cleared only if every record is matched
+func (s *Session) Restore(dest interface{}) (int64, error) {
+	s.initStatemnt()
+	defer s.resetStatement()
+	s.statement.Update()
+	m, entities, err := s.pkTarget(dest, UpdateExpectSliceOrStruct)
+	if err != nil {
+		return 0, err
+	}
+	if m.SoftDelete == nil {
+		return 0, ModelMissingDeletedAt
+	}
+	zero := m.SoftDelete.notDeletedValue()
+	s.statement.Columns(m.SoftDelete.Name).Values([]interface{}{zero}).Where(Ne{m.SoftDelete.Name: zero})
+	affected, err := s.execPkStatements("Restore", m, entities, 1)
+	if err == nil && affected == int64(len(entities)) {
+		m.SoftDelete.setDeletedAt(entities, func(fv reflect.Value) {
+			fv.Set(reflect.Zero(fv.Type()))
+		})
+	}
+	return affected, err
+}
+
+// setDeletedAt set deletedAt field of records by set, it is called after statement
+// matched every record so records are not shown deleted or restored by failed statement,
+// rollback of transaction the statement ran in later is not tracked
+func (f *Field) setDeletedAt(entities []reflect.Value, set func(fv reflect.Value)) {
+	for _, entity := range entities {
+		if entity.CanAddr() {
+			set(f.settable(entity))
+		}
+	}
+}
+
+// softDeleteOf return model with deletedAt field of table e.g. "book b", model is
+// known once it is registered or used, nil if there is none
+func softDeleteOf(table string) *Model {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return nil
+	}
+	if m, ok := softDeleteModels.Load(fields[0]); ok {
+		return m.(*Model)
+	}
+	return nil
+}
+
+// notDeleted return condition of records not soft deleted, column is qualified
+// by table alias if statement has joins or CTEs. it filters the base statement
+// only, statements of Union, Intersect, Except and CTEs are not filtered
+func (m *Model) notDeleted(st *Statement) interface{} {
+	column := m.SoftDelete.Name
+	if len(st.joins) > 0 || len(st.ctes) > 0 {
+		column = st.tableAlias() + "." + column
+	}
+	return Eq{column: m.SoftDelete.notDeletedValue()}
+}
+
+// notDeletedValue return value of deletedAt field of records not deleted, it is 0
+// for integer field and NULL for others
+func (f *Field) notDeletedValue() interface{} {
+	if f.Column.Type.Kind() != reflect.Ptr && isInteger(f.Column.Type) {
+		return 0
+	}
+	return nil
+}
+
+// deletedValue return value of deletedAt field deleted at t
+func (f *Field) deletedValue(t time.Time) interface{} {
+	v := reflect.New(f.Column.Type).Elem()
+	setTime(v, f.timeUnit, t)
+	return reflect.Indirect(v).Interface()
+}
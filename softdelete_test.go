@@ -0,0 +1,113 @@
+package mini_orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type softBook struct {
+	ID        int64 `sql:"pk,autoincr"`
+	Name      string
+	DeletedAt *time.Time `sql:"deletedAt"`
+}
+
+func (b *softBook) TableName() string {
+	return "soft_book"
+}
+
+func TestSoftDelete(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	db := &DB{dialect: PostgresDialect}
+	db.SetClock(func() time.Time { return now })
+	s := dryRunSession(db)
+
+	books := []*softBook{{ID: 1}, {ID: 2}}
+	_, err := s.Delete(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "UPDATE soft_book SET deleted_at = $1 WHERE deleted_at IS NULL AND id IN ($2,$3)", Args: []interface{}{now, int64(1), int64(2)}})
+	assert.True(t, books[0].DeletedAt == nil)
+
+	_, err = s.HardDelete(&softBook{ID: 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "DELETE FROM soft_book WHERE id IN ($1)", Args: []interface{}{int64(3)}})
+
+	_, err = s.Restore(books[0])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "UPDATE soft_book SET deleted_at = $1 WHERE deleted_at IS NOT NULL AND id IN ($2)", Args: []interface{}{nil, int64(1)}})
+
+	_, err = s.Insert(&softBook{Name: "liubin"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "INSERT INTO soft_book (name,deleted_at) VALUES ($1,$2)", Args: []interface{}{"liubin", nil}})
+
+	_, err = s.Update(&softBook{ID: 2, Name: "laojun", DeletedAt: &now})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "UPDATE soft_book SET name = $1 WHERE id = $2", Args: []interface{}{"laojun", int64(2)}})
+}
+
+func TestSoftDeleteFilter(t *testing.T) {
+	s := dryRunSession(nil)
+	books := make([]*softBook, 0)
+	err := s.Select().Where(Eq{"name": "liubin"}).FindAll(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT * FROM soft_book WHERE name = $1 AND deleted_at IS NULL", Args: []interface{}{"liubin"}})
+
+	_, err = s.Select().Model(&softBook{}).Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT count(*) FROM soft_book WHERE deleted_at IS NULL"})
+
+	assert.Equal(t, Register(&softBook{}), nil)
+	_, err = s.Select().From("soft_book").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT count(*) FROM soft_book WHERE deleted_at IS NULL"})
+	_, err = s.Select().From("soft_book").Unscoped().Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT count(*) FROM soft_book"})
+
+	err = s.Select().Unscoped().FindAll(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT * FROM soft_book"})
+
+	err = s.Select("b.*").From("soft_book b").Join("soft_book p ON p.id = b.id").FindAll(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT b.* FROM soft_book b JOIN soft_book p ON p.id = b.id WHERE b.deleted_at IS NULL"})
+
+	recent := (&Statement{}).Select("id").From("soft_book").Where(GT{"id": 10})
+	err = s.Select().With("recent", recent).Where(GT{"id": 1}).FindAll(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "WITH recent AS (SELECT id FROM soft_book WHERE id > $1) SELECT * FROM soft_book WHERE id > $2 AND soft_book.deleted_at IS NULL", Args: []interface{}{10, 1}})
+
+	// statements of set operations are not filtered
+	err = s.Select().Union((&Statement{}).Select("*").From("soft_book")).FindAll(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{SQL: "SELECT * FROM soft_book WHERE deleted_at IS NULL UNION SELECT * FROM soft_book"})
+}
+
+func TestSoftDeleteField(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	engine := stubEngine(t, now)
+
+	book := &softBook{ID: 1}
+	rowcount, err := engine.NewSession().Delete(book)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(1))
+	assert.Equal(t, *book.DeletedAt, now)
+	rowcount, err = engine.NewSession().Restore(book)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(1))
+	assert.True(t, book.DeletedAt == nil)
+
+	// stub matches one row of the two records, so neither is shown as deleted
+	books := []*softBook{{ID: 1}, {ID: 2}}
+	rowcount, err = engine.NewSession().Delete(&books)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rowcount, int64(1))
+	assert.True(t, books[0].DeletedAt == nil)
+	assert.True(t, books[1].DeletedAt == nil)
+
+	missing := &failingBook{ID: 1}
+	_, err = engine.NewSession().Delete(missing)
+	assert.NotEqual(t, err, nil)
+	assert.True(t, missing.DeletedAt == nil)
+}
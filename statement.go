@@ -1,6 +1,9 @@
 package mini_orm
 
 import (
+	"fmt"
+	"strings"
+
 	sq "github.com/Masterminds/squirrel"
 )
 
@@ -15,6 +18,32 @@ const (
 	SelectStatement  StatementType = 4
 )
 
+const (
+	unionOperator     = "UNION"
+	unionAllOperator  = "UNION ALL"
+	intersectOperator = "INTERSECT"
+	exceptOperator    = "EXCEPT"
+)
+
+// setOperation combine statement with operator e.g. UNION
+type setOperation struct {
+	operator  string
+	statement *Statement
+}
+
+// commonTableExpr named sub statement used by WITH clause
+type commonTableExpr struct {
+	name      string
+	statement *Statement
+}
+
+// joinClause join table with condition e.g. LEFT JOIN tree ON tree.id = c.parent_id
+type joinClause struct {
+	kind   string
+	clause string
+	args   []interface{}
+}
+
 // Statement statement
 type Statement struct {
 	stType     StatementType
@@ -22,9 +51,22 @@ type Statement struct {
 	columns    []string
 	limit      uint64
 	offset     uint64
-	orderBys   []string
+	orderBys   []orderBy
+	sortable   map[string]bool
 	conditions []Condition
 	values     [][]interface{}
+	setOps     []setOperation
+	ctes       []commonTableExpr
+	recursive  bool
+	joins      []joinClause
+	windows    []*WindowExpr
+	jsonCols   []jsonColumn
+	source     *Statement
+	comments   map[string]string
+	hints      []string
+	indexes    []string
+	global     bool
+	dialect    Dialect
 }
 
 // Reset Statement Reset
@@ -35,8 +77,107 @@ func (st *Statement) Reset() {
 	st.offset = 0
 	st.limit = 0
 	st.conditions = make([]Condition, 0)
-	st.orderBys = make([]string, 0)
+	st.orderBys = make([]orderBy, 0)
+	st.sortable = nil
 	st.values = make([][]interface{}, 0)
+	st.setOps = make([]setOperation, 0)
+	st.ctes = make([]commonTableExpr, 0)
+	st.recursive = false
+	st.joins = make([]joinClause, 0)
+	st.windows = make([]*WindowExpr, 0)
+	st.jsonCols = make([]jsonColumn, 0)
+	st.source = nil
+	st.comments = nil
+	st.hints = make([]string, 0)
+	st.indexes = make([]string, 0)
+	st.global = false
+}
+
+// Clone return deep copy of statement, so a base statement can be branched safely
+func (st *Statement) Clone() *Statement {
+	if st == nil {
+		return nil
+	}
+	c := &Statement{
+		stType:    st.stType,
+		table:     st.table,
+		limit:     st.limit,
+		offset:    st.offset,
+		recursive: st.recursive,
+		global:    st.global,
+		dialect:   st.dialect,
+	}
+	c.columns = append(make([]string, 0, len(st.columns)), st.columns...)
+	c.orderBys = append(make([]orderBy, 0, len(st.orderBys)), st.orderBys...)
+	c.sortable = st.sortable
+	c.conditions = append(make([]Condition, 0, len(st.conditions)), st.conditions...)
+	c.values = make([][]interface{}, 0, len(st.values))
+	for _, v := range st.values {
+		c.values = append(c.values, append(make([]interface{}, 0, len(v)), v...))
+	}
+	c.setOps = make([]setOperation, 0, len(st.setOps))
+	for _, op := range st.setOps {
+		c.setOps = append(c.setOps, setOperation{op.operator, op.statement.Clone()})
+	}
+	c.ctes = make([]commonTableExpr, 0, len(st.ctes))
+	for _, cte := range st.ctes {
+		c.ctes = append(c.ctes, commonTableExpr{cte.name, cte.statement.Clone()})
+	}
+	c.joins = make([]joinClause, 0, len(st.joins))
+	for _, j := range st.joins {
+		c.joins = append(c.joins, joinClause{j.kind, j.clause, append(make([]interface{}, 0, len(j.args)), j.args...)})
+	}
+	c.windows = make([]*WindowExpr, 0, len(st.windows))
+	for _, w := range st.windows {
+		c.windows = append(c.windows, w.Clone())
+	}
+	c.jsonCols = append(make([]jsonColumn, 0, len(st.jsonCols)), st.jsonCols...)
+	c.source = st.source.Clone()
+	if st.comments != nil {
+		c.comments = make(map[string]string, len(st.comments))
+		for k, v := range st.comments {
+			c.comments[k] = v
+		}
+	}
+	c.hints = append(make([]string, 0, len(st.hints)), st.hints...)
+	c.indexes = append(make([]string, 0, len(st.indexes)), st.indexes...)
+	return c
+}
+
+// countStatement return `SELECT count(*)` statement with the same conditions,
+// order by, limit and offset are ignored. set operations and columns which may
+// change number of rows e.g. DISTINCT name or max(id) are counted by CTE
+func (st *Statement) countStatement() *Statement {
+	if len(st.setOps) > 0 || !st.plainColumns() {
+		sub := st.Clone()
+		sub.orderBys = make([]orderBy, 0)
+		sub.limit = 0
+		sub.offset = 0
+		c := (&Statement{}).Select("count(*)").With("counted", sub).From("counted").SetDialect(st.dialect)
+		c.comments, sub.comments = sub.comments, nil
+		return c
+	}
+	c := st.Clone()
+	c.stType = SelectStatement
+	c.columns = []string{"count(*)"}
+	c.windows = make([]*WindowExpr, 0)
+	c.jsonCols = make([]jsonColumn, 0)
+	c.orderBys = make([]orderBy, 0)
+	c.limit = 0
+	c.offset = 0
+	return c
+}
+
+// plainColumns return true if columns are column names or *, so count(*) of the
+// statement is the number of its rows
+func (st *Statement) plainColumns() bool {
+	for _, c := range st.columns {
+		c = strings.TrimSuffix(strings.TrimSpace(c), ".*")
+		if c != "*" && !identifier.MatchString(c) {
+			return false
+		}
+	}
+	return true
 }
 
 // Select set select statment
@@ -63,6 +204,18 @@ func (st *Statement) Columns(columns ...string) *Statement {
 	return st
 }
 
+// Window add window function columns after columns, select "*" with windows if columns not set
+func (st *Statement) Window(exprs ...*WindowExpr) *Statement {
+	st.windows = append(st.windows, exprs...)
+	return st
+}
+
+// JSONSelect add text of json path as column e.g. JSONSelect("meta->settings->theme", "theme")
+func (st *Statement) JSONSelect(path, alias string) *Statement {
+	st.jsonCols = append(st.jsonCols, jsonColumn{path, alias})
+	return st
+}
+
 // Insert set insert statement
 func (st *Statement) Insert() *Statement {
 	st.Reset()
@@ -70,6 +223,13 @@ func (st *Statement) Insert() *Statement {
 	return st
 }
 
+// InsertSelect set select statement as source of insert statement
+// e.g. INSERT INTO archive (id, name) SELECT id, name FROM codebook WHERE ...
+func (st *Statement) InsertSelect(source *Statement) *Statement {
+	st.source = source
+	return st
+}
+
 // Update set update statement
 func (st *Statement) Update() *Statement {
 	st.Reset()
@@ -104,40 +264,261 @@ func (st *Statement) Where(expr ...interface{}) *Statement {
 	return st
 }
 
-// OrderBy set orderby
-func (st *Statement) OrderBy(orderby ...string) *Statement {
+// OrderBy add order by column e.g. OrderBy("created_at", Desc, NullsLast),
+// column is validated when gen SQL so it is safe for user input. direction is
+// required so raw order by of old OrderBy("id desc") does not compile, use
+// OrderByRaw("id desc") for it
+func (st *Statement) OrderBy(column string, direction OrderOption, nulls ...OrderOption) *Statement {
+	st.orderBys = append(st.orderBys, newOrderBy(column, append([]OrderOption{direction}, nulls...)...))
+	return st
+}
+
+// OrderByRaw add raw order by e.g. OrderByRaw("id desc"), it is spliced into SQL
+// and never use it with user input
+func (st *Statement) OrderByRaw(orderby ...string) *Statement {
 	for _, o := range orderby {
-		st.orderBys = append(st.orderBys, o)
+		st.orderBys = append(st.orderBys, orderBy{raw: o})
 	}
 	return st
 }
 
+// AllowOrderBy set whitelist of OrderBy columns
+func (st *Statement) AllowOrderBy(columns ...string) *Statement {
+	st.sortable = make(map[string]bool)
+	for _, c := range columns {
+		st.sortable[c] = true
+	}
+	return st
+}
+
+// validateOrderBy check OrderBy columns against whitelist, or model fields
+// if whitelist not set, raw order by is not checked
+func (st *Statement) validateOrderBy(m *Model) error {
+	allowed := st.sortable
+	if allowed == nil && m != nil {
+		allowed = make(map[string]bool)
+		for name := range m.Fields {
+			allowed[name] = true
+		}
+	}
+	for _, o := range st.orderBys {
+		if err := o.validate(allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderByClauses render order by items with dialect
+func (st *Statement) orderByClauses() []string {
+	clauses := make([]string, 0, len(st.orderBys))
+	for _, o := range st.orderBys {
+		clauses = append(clauses, o.toSQL(st.dialect)...)
+	}
+	return clauses
+}
+
 // Values set values
 func (st *Statement) Values(val []interface{}) *Statement {
 	st.values = append(st.values, val)
 	return st
 }
 
+// Union combine select statement with others by UNION
+// orderBy, limit and offset of st apply to the combined result
+func (st *Statement) Union(others ...*Statement) *Statement {
+	return st.combine(unionOperator, others...)
+}
+
+// UnionAll combine select statement with others by UNION ALL
+func (st *Statement) UnionAll(others ...*Statement) *Statement {
+	return st.combine(unionAllOperator, others...)
+}
+
+// Intersect combine select statement with others by INTERSECT
+func (st *Statement) Intersect(others ...*Statement) *Statement {
+	return st.combine(intersectOperator, others...)
+}
+
+// Except combine select statement with others by EXCEPT
+func (st *Statement) Except(others ...*Statement) *Statement {
+	return st.combine(exceptOperator, others...)
+}
+
+func (st *Statement) combine(operator string, others ...*Statement) *Statement {
+	for _, o := range others {
+		st.setOps = append(st.setOps, setOperation{operator, o})
+	}
+	return st
+}
+
+// With declare common table expression, name can contain columns e.g. "tree(id, parent_id)"
+// and could be referenced in From or Join
+func (st *Statement) With(name string, sub *Statement) *Statement {
+	st.ctes = append(st.ctes, commonTableExpr{name, sub})
+	return st
+}
+
+// WithRecursive declare recursive common table expression, sub usually is
+// `base UNION ALL recursive` statement
+func (st *Statement) WithRecursive(name string, sub *Statement) *Statement {
+	st.recursive = true
+	return st.With(name, sub)
+}
+
+// Join add JOIN clause e.g. Join("tree ON tree.id = c.parent_id")
+func (st *Statement) Join(join string, args ...interface{}) *Statement {
+	return st.joinWith("JOIN", join, args...)
+}
+
+// LeftJoin add LEFT JOIN clause
+func (st *Statement) LeftJoin(join string, args ...interface{}) *Statement {
+	return st.joinWith("LEFT JOIN", join, args...)
+}
+
+// RightJoin add RIGHT JOIN clause
+func (st *Statement) RightJoin(join string, args ...interface{}) *Statement {
+	return st.joinWith("RIGHT JOIN", join, args...)
+}
+
+func (st *Statement) joinWith(kind, join string, args ...interface{}) *Statement {
+	st.joins = append(st.joins, joinClause{kind, join, args})
+	return st
+}
+
+// AllowGlobal allow update or delete statement without conditions, which
+// affects every row of table
+func (st *Statement) AllowGlobal() *Statement {
+	st.global = true
+	return st
+}
+
+// Comment add key/value tag rendered as sqlcommenter comment at the end of SQL
+// e.g. SELECT * FROM codebook /*request_id='42',route='%2Fbooks'*/
+func (st *Statement) Comment(key, value string) *Statement {
+	if st.comments == nil {
+		st.comments = make(map[string]string)
+	}
+	st.comments[key] = value
+	return st
+}
+
+// Hint add optimizer hint e.g. Hint("MAX_EXECUTION_TIME(1000)") for mysql or
+// Hint("SeqScan(codebook)") for postgres with pg_hint_plan
+func (st *Statement) Hint(hints ...string) *Statement {
+	st.hints = append(st.hints, hints...)
+	return st
+}
+
+// UseIndex hint indexes of table, it is `USE INDEX (...)` for mysql select and
+// IndexScan hint of pg_hint_plan for postgres
+func (st *Statement) UseIndex(indexes ...string) *Statement {
+	st.indexes = append(st.indexes, indexes...)
+	return st
+}
+
+// SetDialect set dialect used to gen SQL e.g. placeholder $1 for postgres
+func (st *Statement) SetDialect(d Dialect) *Statement {
+	st.dialect = d
+	return st
+}
+
 // ToSQL gen SQl
 func (st *Statement) ToSQL() (string, []interface{}, error) {
+	query, args, err := st.toSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	query, err = st.dialect.placeholder().ReplacePlaceholders(query)
+	if err != nil {
+		return "", nil, err
+	}
+	if st.dialect == PostgresDialect {
+		if query, err = st.withHint(query); err != nil {
+			return "", nil, err
+		}
+	}
+	if comment := sqlComment(st.comments); comment != "" {
+		query += " " + comment
+	}
+	return query, args, nil
+}
+
+// toSQL gen SQL with ? placeholder, sub statements are rendered by it
+// so placeholders are replaced only once
+func (st *Statement) toSQL() (string, []interface{}, error) {
 	if st.table == "" {
 		return "", nil, StatementTableNotSet
 	}
 	if st.stType == UnknownStatement {
 		return "", nil, StatementTypeNotSet
 	}
+	if err := st.validateOrderBy(nil); err != nil {
+		return "", nil, err
+	}
+	if (st.stType == UpdateStatement || st.stType == DeleteStatement) && !st.global {
+		filtered, err := st.hasConditions()
+		if err != nil {
+			return "", nil, err
+		}
+		if !filtered {
+			return "", nil, GlobalUpdateOrDelete
+		}
+	}
+	query, args, err := st.build()
+	if err != nil {
+		return "", nil, err
+	}
+	if st.dialect != PostgresDialect {
+		if query, err = st.withHint(query); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(st.ctes) > 0 {
+		prefix, prefixArgs, err := st.withToSQL()
+		if err != nil {
+			return "", nil, err
+		}
+		query = prefix + " " + query
+		args = append(prefixArgs, args...)
+	}
+	return query, args, nil
+}
+
+// subSQL gen SQL of sub statement with dialect of st
+func (st *Statement) subSQL(sub *Statement) (string, []interface{}, error) {
+	return sub.Clone().SetDialect(st.dialect).toSQL()
+}
+
+// withToSQL gen `WITH [RECURSIVE] name AS (...), ...` clause
+func (st *Statement) withToSQL() (string, []interface{}, error) {
+	args := make([]interface{}, 0)
+	exprs := make([]string, 0, len(st.ctes))
+	for _, cte := range st.ctes {
+		if cte.statement == nil {
+			return "", nil, StatementTypeNotSet
+		}
+		query, subArgs, err := st.subSQL(cte.statement)
+		if err != nil {
+			return "", nil, err
+		}
+		exprs = append(exprs, fmt.Sprintf("%s AS (%s)", cte.name, query))
+		args = append(args, subArgs...)
+	}
+	if st.recursive {
+		return "WITH RECURSIVE " + strings.Join(exprs, ", "), args, nil
+	}
+	return "WITH " + strings.Join(exprs, ", "), args, nil
+}
+
+// build gen SQL without WITH clause
+func (st *Statement) build() (string, []interface{}, error) {
 	switch st.stType {
 	case SelectStatement:
-		var builder sq.SelectBuilder
-		if len(st.columns) > 0 {
-			builder = sq.Select(st.columns...)
-		} else {
-			builder = sq.Select("*")
-		}
-		builder = builder.From(st.table)
-		for _, c := range st.conditions {
-			builder = builder.Where(st.ConvertCondition(c.Expr))
+		if len(st.setOps) > 0 {
+			return st.setOperationToSQL()
 		}
+		builder := st.selectBuilder()
 		if st.offset > 0 {
 			builder = builder.Offset(st.offset)
 		}
@@ -145,7 +526,7 @@ func (st *Statement) ToSQL() (string, []interface{}, error) {
 			builder = builder.Limit(st.limit)
 		}
 		if len(st.orderBys) > 0 {
-			builder = builder.OrderBy(st.orderBys...)
+			builder = builder.OrderBy(st.orderByClauses()...)
 		}
 		return builder.ToSql()
 	case DeleteStatement:
@@ -160,10 +541,13 @@ func (st *Statement) ToSQL() (string, []interface{}, error) {
 			builder = builder.Limit(st.limit)
 		}
 		if len(st.orderBys) > 0 {
-			builder = builder.OrderBy(st.orderBys...)
+			builder = builder.OrderBy(st.orderByClauses()...)
 		}
 		return builder.ToSql()
 	case InsertStatement:
+		if st.source != nil {
+			return st.insertSelectToSQL()
+		}
 		builder := sq.Insert(st.table)
 		builder = builder.Columns(st.columns...)
 		for _, v := range st.values {
@@ -189,33 +573,142 @@ func (st *Statement) ToSQL() (string, []interface{}, error) {
 			builder = builder.Limit(st.limit)
 		}
 		if len(st.orderBys) > 0 {
-			builder = builder.OrderBy(st.orderBys...)
+			builder = builder.OrderBy(st.orderByClauses()...)
 		}
 		return builder.ToSql()
 	}
 	return "", nil, StatementTypeNotSet
 }
 
+// selectBuilder build select without order by, limit and offset
+func (st *Statement) selectBuilder() sq.SelectBuilder {
+	columns := make([]string, 0, len(st.columns)+len(st.windows))
+	columns = append(columns, st.columns...)
+	if len(columns) == 0 {
+		columns = append(columns, "*")
+	}
+	for _, w := range st.windows {
+		columns = append(columns, w.String())
+	}
+	for _, j := range st.jsonCols {
+		columns = append(columns, jsonExtract(st.dialect, j.path)+" AS "+j.alias)
+	}
+	builder := sq.Select(columns...).From(st.tableExpr())
+	for _, j := range st.joins {
+		builder = builder.JoinClause(j.kind+" "+j.clause, j.args...)
+	}
+	for _, c := range st.conditions {
+		builder = builder.Where(st.ConvertCondition(c.Expr))
+	}
+	return builder
+}
+
+// hasConditions return true if any condition filters rows, empty Eq{} or AND{}
+// renders nothing or (1=1)
+func (st *Statement) hasConditions() (bool, error) {
+	for _, c := range st.conditions {
+		sqlizer, ok := st.ConvertCondition(c.Expr).(sq.Sqlizer)
+		if !ok {
+			return true, nil
+		}
+		query, _, err := sqlizer.ToSql()
+		if err != nil {
+			return false, err
+		}
+		switch strings.TrimSpace(query) {
+		case "", "(1=1)", "1=1":
+		default:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// conditionArgs return count of bind parameters used by where conditions
+func (st *Statement) conditionArgs() (int, error) {
+	n := 0
+	for _, c := range st.conditions {
+		sqlizer, ok := st.ConvertCondition(c.Expr).(sq.Sqlizer)
+		if !ok {
+			continue
+		}
+		_, args, err := sqlizer.ToSql()
+		if err != nil {
+			return 0, err
+		}
+		n += len(args)
+	}
+	return n, nil
+}
+
+// hasTail return true if statement has order by, limit or offset
+func (st *Statement) hasTail() bool {
+	return len(st.orderBys) > 0 || st.limit > 0 || st.offset > 0
+}
+
+// setOperationToSQL gen SQL like `SELECT ... UNION (SELECT ...) ORDER BY ... LIMIT ...`,
+// operations are applied left to right, so the left side is parenthesized before
+// INTERSECT following UNION or EXCEPT as INTERSECT binds tighter than them
+func (st *Statement) setOperationToSQL() (string, []interface{}, error) {
+	query, args, err := st.selectBuilder().ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	for i, op := range st.setOps {
+		if op.statement == nil || op.statement.stType != SelectStatement {
+			return "", nil, SetOperationExpectSelect
+		}
+		subQuery, subArgs, err := st.subSQL(op.statement)
+		if err != nil {
+			return "", nil, err
+		}
+		if op.statement.hasTail() || len(op.statement.setOps) > 0 || len(op.statement.ctes) > 0 {
+			subQuery = "(" + subQuery + ")"
+		}
+		if i > 0 && op.operator == intersectOperator && st.setOps[i-1].operator != intersectOperator {
+			query = "(" + query + ")"
+		}
+		query += " " + op.operator + " " + subQuery
+		args = append(args, subArgs...)
+	}
+	buf := strings.Builder{}
+	buf.WriteString(query)
+	if len(st.orderBys) > 0 {
+		buf.WriteString(" ORDER BY " + strings.Join(st.orderByClauses(), ", "))
+	}
+	if st.limit > 0 {
+		buf.WriteString(fmt.Sprintf(" LIMIT %d", st.limit))
+	}
+	if st.offset > 0 {
+		buf.WriteString(fmt.Sprintf(" OFFSET %d", st.offset))
+	}
+	return buf.String(), args, nil
+}
+
+// insertSelectToSQL gen SQL like `INSERT INTO table (columns) SELECT ...`
+func (st *Statement) insertSelectToSQL() (string, []interface{}, error) {
+	if st.source.stType != SelectStatement {
+		return "", nil, InsertSelectExpectSelect
+	}
+	query, args, err := st.subSQL(st.source)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(st.columns) > 0 {
+		return fmt.Sprintf("INSERT INTO %s (%s) %s", st.table, strings.Join(st.columns, ","), query), args, nil
+	}
+	return fmt.Sprintf("INSERT INTO %s %s", st.table, query), args, nil
+}
+
 // ConvertCondition convert condition to sq condition it will panic if convert not found
 func (st *Statement) ConvertCondition(c interface{}) interface{} {
 	switch expr := c.(type) {
-	case Eq, Ne, Like, NotLike, GT, GTE, LT, LTE:
+	case AND, OR, JSONEq, JSONContains, JSONHasKey, AnyOf, TupleIn:
+		sqlize := expr.(DialectSqlizer)
+		return sqlize.ToDialectSqlizer(st.dialect)
+	case Eq, Ne, Like, NotLike, GT, GTE, LT, LTE, ArrayContains, ArrayOverlaps:
 		sqlize := expr.(Sqlizer)
 		return sqlize.ToSqlizer()
-	case AND:
-		e := sq.And{}
-		for _, v := range expr {
-			s := v.(Sqlizer)
-			e = append(e, s.ToSqlizer())
-		}
-		return e
-	case OR:
-		e := sq.Or{}
-		for _, v := range expr {
-			s := v.(Sqlizer)
-			e = append(e, s.ToSqlizer())
-		}
-		return e
 	default:
 		panic("ConvertCondition not support")
 	}
@@ -0,0 +1,319 @@
+package mini_orm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementUnion(t *testing.T) {
+	archive := (&Statement{}).Select("id", "name").From("codebook_archive").Where(Eq{"name": "laojun"})
+	st := (&Statement{}).Select("id", "name").From("codebook").Where(Eq{"name": "liubin"})
+	sql, args, err := st.UnionAll(archive).OrderByRaw("id desc").Limit(10).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id, name FROM codebook WHERE name = ? UNION ALL SELECT id, name FROM codebook_archive WHERE name = ? ORDER BY id desc LIMIT 10")
+	assert.Equal(t, args, []interface{}{"liubin", "laojun"})
+}
+
+func TestStatementIntersectExcept(t *testing.T) {
+	a := (&Statement{}).Select("id").From("a")
+	b := (&Statement{}).Select("id").From("b").Limit(5)
+	c := (&Statement{}).Select("id").From("c")
+	sql, _, err := a.Intersect(b).Except(c).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id FROM a INTERSECT (SELECT id FROM b LIMIT 5) EXCEPT SELECT id FROM c")
+
+	_, _, err = (&Statement{}).Select().From("a").Union((&Statement{}).Delete().From("b")).ToSQL()
+	assert.Equal(t, err, SetOperationExpectSelect)
+
+	a = (&Statement{}).Select("id").From("a")
+	sql, _, err = a.Union((&Statement{}).Select("id").From("b")).Intersect(c).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "(SELECT id FROM a UNION SELECT id FROM b) INTERSECT SELECT id FROM c")
+
+	a = (&Statement{}).Select("id").From("a")
+	recent := (&Statement{}).Select("id").From("b").With("x", (&Statement{}).Select("id").From("c").Where(GT{"id": 1})).Where(GT{"id": 2})
+	sql, args, err := a.Union(recent).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id FROM a UNION (WITH x AS (SELECT id FROM c WHERE id > ?) SELECT id FROM b WHERE id > ?)")
+	assert.Equal(t, args, []interface{}{1, 2})
+}
+
+func TestStatementWithRecursive(t *testing.T) {
+	base := (&Statement{}).Select("id", "parent_id", "name").From("category").Where(Eq{"id": 1})
+	recursive := (&Statement{}).Select("c.id", "c.parent_id", "c.name").From("category c").Join("tree ON tree.id = c.parent_id")
+	st := (&Statement{}).Select("id", "name").WithRecursive("tree(id, parent_id, name)", base.UnionAll(recursive)).From("tree").Where(GT{"id": 2})
+	sql, args, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "WITH RECURSIVE tree(id, parent_id, name) AS (SELECT id, parent_id, name FROM category WHERE id = ? UNION ALL SELECT c.id, c.parent_id, c.name FROM category c JOIN tree ON tree.id = c.parent_id) SELECT id, name FROM tree WHERE id > ?")
+	assert.Equal(t, args, []interface{}{1, 2})
+}
+
+func TestStatementWithJoin(t *testing.T) {
+	active := (&Statement{}).Select("id").From("users").Where(Eq{"status": "active"})
+	st := (&Statement{}).Select("o.id").With("active", active).From("orders o").LeftJoin("active a ON a.id = o.user_id AND o.total > ?", 100).Where(Eq{"o.state": "paid"})
+	sql, args, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "WITH active AS (SELECT id FROM users WHERE status = ?) SELECT o.id FROM orders o LEFT JOIN active a ON a.id = o.user_id AND o.total > ? WHERE o.state = ?")
+	assert.Equal(t, args, []interface{}{"active", 100, "paid"})
+}
+
+func TestStatementWindow(t *testing.T) {
+	st := (&Statement{}).Select().From("codebook").Window(
+		RowNumber().PartitionBy("name").OrderBy("id desc").As("rn"),
+		Sum("amount").OrderBy("id").Frame("ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW").As("total"),
+		Lag("amount", 1).OrderBy("id").As("prev_amount"),
+	)
+	sql, _, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT *, ROW_NUMBER() OVER (PARTITION BY name ORDER BY id desc) AS rn, SUM(amount) OVER (ORDER BY id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS total, LAG(amount, 1) OVER (ORDER BY id) AS prev_amount FROM codebook")
+
+	sql, _, err = (&Statement{}).Select("id").From("codebook").Window(Rank().OrderBy("id").As("rank")).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id, RANK() OVER (ORDER BY id) AS rank FROM codebook")
+}
+
+func TestStatementClone(t *testing.T) {
+	base := (&Statement{}).Select().From("codebook").Where(Eq{"name": "liubin"})
+	a := base.Clone().Where(GT{"id": 7}).OrderBy("id", Desc)
+	b := base.Clone().Limit(1)
+
+	sql, args, err := base.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE name = ?")
+	assert.Equal(t, args, []interface{}{"liubin"})
+	sql, args, err = a.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE name = ? AND id > ? ORDER BY id DESC")
+	assert.Equal(t, args, []interface{}{"liubin", 7})
+	sql, _, err = b.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE name = ? LIMIT 1")
+}
+
+func TestStatementDialectPlaceholder(t *testing.T) {
+	archive := (&Statement{}).Select("id").From("codebook_archive").Where(Eq{"name": "laojun"})
+	st := (&Statement{}).Select("id").From("codebook").Where(Eq{"name": "liubin"}).Union(archive).SetDialect(PostgresDialect)
+	sql, args, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id FROM codebook WHERE name = $1 UNION SELECT id FROM codebook_archive WHERE name = $2")
+	assert.Equal(t, args, []interface{}{"liubin", "laojun"})
+}
+
+func TestStatementCount(t *testing.T) {
+	st := (&Statement{}).Select("id", "name").From("codebook").Where(Eq{"name": "liubin"}).Window(RowNumber().As("rn")).OrderBy("id", Desc).Limit(10).Offset(20)
+	sql, args, err := st.countStatement().ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT count(*) FROM codebook WHERE name = ?")
+	assert.Equal(t, args, []interface{}{"liubin"})
+	// st is not changed
+	sql, _, err = st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id, name, ROW_NUMBER() OVER () AS rn FROM codebook WHERE name = ? ORDER BY id DESC LIMIT 10 OFFSET 20")
+
+	archive := (&Statement{}).Select("id").From("codebook_archive")
+	sql, _, err = (&Statement{}).Select("id").From("codebook").Union(archive).OrderBy("id", Asc).Limit(5).countStatement().ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "WITH counted AS (SELECT id FROM codebook UNION SELECT id FROM codebook_archive) SELECT count(*) FROM counted")
+
+	for _, columns := range [][]string{{"DISTINCT name"}, {"name", "count(*) AS n"}} {
+		sql, _, err = (&Statement{}).Select(columns...).From("codebook").Where(Eq{"name": "liubin"}).OrderBy("name", Asc).Limit(10).countStatement().ToSQL()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, sql, "WITH counted AS (SELECT "+strings.Join(columns, ", ")+" FROM codebook WHERE name = ?) SELECT count(*) FROM counted")
+	}
+	sql, _, err = (&Statement{}).Select("c.*").From("codebook c").countStatement().ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT count(*) FROM codebook c")
+}
+
+type scopedBook struct {
+	ID     int64
+	Status string
+}
+
+func (b *scopedBook) TableName() string {
+	return "scoped_book"
+}
+
+func (b *scopedBook) DefaultScopes() []Scope {
+	return []Scope{func(st *Statement) *Statement {
+		return st.Where(Eq{"status": "active"})
+	}}
+}
+
+func TestSessionScopes(t *testing.T) {
+	recent := func(st *Statement) *Statement {
+		return st.OrderBy("id", Desc).Limit(10)
+	}
+	m := NewModel(reflect.ValueOf(&scopedBook{}))
+	s := &Session{statement: (&Statement{}).Select().From(m.TableName)}
+	s.Scopes(recent).applyScopes(m)
+	sql, args, err := s.statement.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM scoped_book WHERE status = ? ORDER BY id DESC LIMIT 10")
+	assert.Equal(t, args, []interface{}{"active"})
+
+	s = &Session{statement: (&Statement{}).Select().From(m.TableName)}
+	s.Scopes(recent).Unscoped().applyScopes(m)
+	sql, _, err = s.statement.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM scoped_book ORDER BY id DESC LIMIT 10")
+
+	ds := dryRunSession(nil)
+	err = ds.Select().Scopes(recent).FindOne(&scopedBook{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, ds).SQL, "SELECT * FROM scoped_book WHERE status = $1 ORDER BY id DESC LIMIT 1")
+
+	plan, err := stubEngine(t, time.Time{}).NewSession().Select().Scopes(recent).Explain(&scopedBook{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, plan.SQL, "SELECT * FROM scoped_book WHERE status = ? ORDER BY id DESC LIMIT 1")
+}
+
+func TestStatementInsertSelect(t *testing.T) {
+	source := (&Statement{}).Select("id", "name").From("codebook").Where(Eq{"name": "liubin"})
+	st := (&Statement{}).Insert().From("codebook_archive").Columns("id", "name").InsertSelect(source).SetDialect(PostgresDialect)
+	sql, args, err := st.ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "INSERT INTO codebook_archive (id,name) SELECT id, name FROM codebook WHERE name = $1")
+	assert.Equal(t, args, []interface{}{"liubin"})
+
+	_, _, err = (&Statement{}).Insert().From("codebook_archive").InsertSelect((&Statement{}).Delete().From("codebook")).ToSQL()
+	assert.Equal(t, err, InsertSelectExpectSelect)
+}
+
+func TestStatementJSONConditions(t *testing.T) {
+	st := (&Statement{}).Select("id").From("setting").JSONSelect("meta->settings->theme", "theme").Where(
+		JSONEq{"meta->settings->theme": "dark"},
+		JSONContains{"meta->settings": map[string]interface{}{"lang": "vi"}},
+		OR{JSONHasKey{"meta->settings": "font"}, Eq{"id": 1}},
+	)
+	sql, args, err := st.Clone().SetDialect(PostgresDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id, meta->'settings'->>'theme' AS theme FROM setting WHERE (meta->'settings'->>'theme' = $1) AND (meta->'settings' @> $2::jsonb) AND ((meta->'settings' ? $3) OR id = $4)")
+	assert.Equal(t, args, []interface{}{"dark", `{"lang":"vi"}`, "font", 1})
+
+	sql, args, err = st.Clone().SetDialect(MySQLDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT id, JSON_UNQUOTE(JSON_EXTRACT(meta, '$.settings.theme')) AS theme FROM setting WHERE (JSON_UNQUOTE(JSON_EXTRACT(meta, '$.settings.theme')) = ?) AND (JSON_CONTAINS(meta, ?, ?)) AND ((JSON_CONTAINS_PATH(meta, 'one', ?)) OR id = ?)")
+	assert.Equal(t, args, []interface{}{"dark", `{"lang":"vi"}`, "$.settings", "$.settings.font", 1})
+}
+
+func TestStatementOrderBy(t *testing.T) {
+	st := (&Statement{}).Select().From("codebook").OrderBy("name", Asc, NullsLast).OrderBy("codebook.id", Desc).OrderByRaw("random()")
+	sql, _, err := st.Clone().SetDialect(PostgresDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook ORDER BY name ASC NULLS LAST, codebook.id DESC, random()")
+	sql, _, err = st.Clone().SetDialect(MySQLDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook ORDER BY name IS NULL ASC, name ASC, codebook.id DESC, random()")
+
+	_, _, err = (&Statement{}).Select().From("codebook").OrderBy("id; DROP TABLE codebook", Asc).ToSQL()
+	assert.True(t, errors.Is(err, OrderByColumnNotAllowed))
+	_, _, err = (&Statement{}).Select().From("codebook").AllowOrderBy("id").OrderBy("password", Asc).ToSQL()
+	assert.True(t, errors.Is(err, OrderByColumnNotAllowed))
+
+	m := NewModel(reflect.ValueOf(&scopedBook{}))
+	st = (&Statement{}).Select().From("scoped_book").OrderBy("scoped_book.status", Asc)
+	assert.Equal(t, st.validateOrderBy(m), nil)
+	st = (&Statement{}).Select().From("scoped_book").OrderBy("password", Asc)
+	assert.True(t, errors.Is(st.validateOrderBy(m), OrderByColumnNotAllowed))
+}
+
+func TestStatementValidateColumns(t *testing.T) {
+	m := NewModel(reflect.ValueOf(&scopedBook{}))
+	st := (&Statement{}).Select("id", "count(*)").From("scoped_book").Where(Eq{"scoped_book.status": "active"}, OR{GT{"id": 1}, Like{"status": "a%"}}).OrderBy("id", Asc)
+	assert.Equal(t, st.validateColumns(m), nil)
+
+	st = (&Statement{}).Select("id", "stauts").From("scoped_book").Where(Eq{"nmae": "laojun"}, AND{LT{"idd": 3}}).OrderBy("status", Asc)
+	err := st.validateColumns(m)
+	assert.NotEqual(t, err, nil)
+	unknown := err.(*UnknownColumnError)
+	assert.Equal(t, unknown.Columns, []string{"stauts", "nmae", "idd"})
+	assert.Equal(t, unknown.Suggestions["stauts"], []string{"status"})
+	assert.Equal(t, unknown.Suggestions["idd"], []string{"id"})
+	assert.Equal(t, err.Error(), "unknown columns of model scoped_book: stauts (did you mean status?); nmae; idd (did you mean id?)")
+}
+
+func TestStatementConditionArgs(t *testing.T) {
+	st := (&Statement{}).Delete().From("codebook").Where(Eq{"name": "laojun"}, OR{Eq{"id": []int{1, 2, 3}}, Like{"remarks": "a%"}})
+	n, err := st.conditionArgs()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, n, 5)
+
+	values := make([]reflect.Value, 0)
+	for i := 0; i < 5; i++ {
+		values = append(values, reflect.ValueOf(i))
+	}
+	chunks := chunkValues(values, 2)
+	assert.Equal(t, len(chunks), 3)
+	assert.Equal(t, len(chunks[2]), 1)
+	assert.Equal(t, len(chunkValues(values, 0)), 5)
+	assert.Equal(t, len(chunkValues(nil, 2)), 0)
+}
+
+func TestStatementCommentAndHint(t *testing.T) {
+	st := (&Statement{}).Select().From("codebook").Where(Eq{"name": "liubin"}).Comment("route", "/books/{id}").Comment("request_id", "it's 42")
+	sql, args, err := st.Clone().SetDialect(PostgresDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT * FROM codebook WHERE name = $1 /*request_id='it%27s%2042',route='%2Fbooks%2F%7Bid%7D'*/")
+	assert.Equal(t, args, []interface{}{"liubin"})
+
+	st = (&Statement{}).Select("id").From("codebook c").Where(Eq{"name": "liubin"}).UseIndex("idx_name").Hint("MAX_EXECUTION_TIME(1000)")
+	sql, _, err = st.Clone().SetDialect(MySQLDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT /*+ MAX_EXECUTION_TIME(1000) */ id FROM codebook c USE INDEX (idx_name) WHERE name = ?")
+	sql, _, err = st.Clone().SetDialect(PostgresDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "/*+ MAX_EXECUTION_TIME(1000) IndexScan(c idx_name) */ SELECT id FROM codebook c WHERE name = $1")
+
+	sql, _, err = (&Statement{}).Delete().From("codebook").Where(Eq{"id": 1}).Hint("NO_INDEX_MERGE(codebook)").SetDialect(MySQLDialect).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "DELETE /*+ NO_INDEX_MERGE(codebook) */ FROM codebook WHERE id = ?")
+
+	_, _, err = (&Statement{}).Select().From("codebook").Hint("x */ DROP TABLE codebook; /*").ToSQL()
+	assert.True(t, errors.Is(err, InvalidHint))
+	_, _, err = (&Statement{}).Select().From("codebook").UseIndex("idx) DROP").ToSQL()
+	assert.True(t, errors.Is(err, InvalidHint))
+}
+
+func TestStatementInterpolate(t *testing.T) {
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	st := (&Statement{}).Select().From("codebook").Where(Eq{"name": "it's"}, Eq{"remarks": nil}, GT{"created": created}, Eq{"active": true}, Eq{"data": []byte{0xde, 0xad}}).Comment("route", "/books?x")
+	sql, err := st.Clone().SetDialect(PostgresDialect).Interpolate()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, `SELECT * FROM codebook WHERE name = 'it''s' AND remarks IS NULL AND created > '2021-03-04 05:06:07+00:00' AND active = TRUE AND data = '\xdead'::bytea /*route='%2Fbooks%3Fx'*/`)
+
+	sql, err = st.Clone().SetDialect(MySQLDialect).Interpolate()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, `SELECT * FROM codebook WHERE name = 'it\'s' AND remarks IS NULL AND created > '2021-03-04 05:06:07' AND active = 1 AND data = X'dead' /*route='%2Fbooks%3Fx'*/`)
+
+	remarks := "a\\b"
+	var missing *string
+	sql, err = (&Statement{}).Select().From("codebook").Where(Eq{"remarks": &remarks}, Eq{"name": missing}, LT{"id": 2.5}).SetDialect(MySQLDialect).Interpolate()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, `SELECT * FROM codebook WHERE remarks = 'a\\b' AND name IS NULL AND id < 2.5`)
+
+	_, err = interpolate(MySQLDialect, "SELECT '?' FROM codebook WHERE id = ?", nil)
+	assert.True(t, errors.Is(err, InterpolateArgsMismatch))
+	sql, err = interpolate(PostgresDialect, "SELECT '$1', meta ? 'x' FROM codebook WHERE id = $1", []interface{}{uint(3)})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "SELECT '$1', meta ? 'x' FROM codebook WHERE id = 3")
+}
+
+func TestStatementGlobal(t *testing.T) {
+	_, _, err := (&Statement{}).Delete().From("codebook").ToSQL()
+	assert.Equal(t, err, GlobalUpdateOrDelete)
+	_, _, err = (&Statement{}).Update().From("codebook").Columns("name").Values([]interface{}{"laojun"}).Where(Eq{}, AND{}).ToSQL()
+	assert.Equal(t, err, GlobalUpdateOrDelete)
+
+	sql, _, err := (&Statement{}).Delete().From("codebook").AllowGlobal().ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "DELETE FROM codebook")
+	sql, _, err = (&Statement{}).Delete().From("codebook").Where(Eq{"id": []int{}}).ToSQL()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sql, "DELETE FROM codebook WHERE (1=0)")
+}
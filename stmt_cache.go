@@ -0,0 +1,152 @@
+package mini_orm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StmtCacheStats statistics of prepared statement cache
+type StmtCacheStats struct {
+	Size      int
+	Capacity  int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// stmtKey prepared statement is bound to node e.g. master, slave:0
+type stmtKey struct {
+	node  string
+	query string
+}
+
+// stmtEntry cached statement, refs counts users between get and release so an
+// evicted statement is closed by its last user instead of under a running query
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache LRU cache of prepared statements
+type stmtCache struct {
+	mu        sync.Mutex
+	capacity  int
+	ll        *list.List
+	items     map[stmtKey]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[stmtKey]*list.Element),
+	}
+}
+
+// get return cached statement or prepare it on node, the statement is pinned until
+// release is called and it must be called once the statement has been executed
+func (c *stmtCache) get(ctx context.Context, node string, db *sql.DB, query string) (*sql.Stmt, func(), error) {
+	if stmt, release, ok := c.lookup(node, query); ok {
+		return stmt, release, nil
+	}
+	key := stmtKey{node, query}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		// prepared by another goroutine meanwhile
+		stmt.Close()
+		c.ll.MoveToFront(e)
+		entry := c.pin(e)
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+	entry := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	c.items[key] = c.ll.PushFront(entry)
+	c.evict()
+	return stmt, func() { c.release(entry) }, nil
+}
+
+// lookup return cached statement of node pinned like get, false and a miss is
+// counted if query is not cached
+func (c *stmtCache) lookup(node, query string) (*sql.Stmt, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[stmtKey{node, query}]
+	if !ok {
+		c.misses++
+		return nil, nil, false
+	}
+	c.ll.MoveToFront(e)
+	c.hits++
+	entry := c.pin(e)
+	return entry.stmt, func() { c.release(entry) }, true
+}
+
+// pin mark statement of e in use, c.mu must be held
+func (c *stmtCache) pin(e *list.Element) *stmtEntry {
+	entry := e.Value.(*stmtEntry)
+	entry.refs++
+	return entry
+}
+
+// release unpin statement, evicted statement is closed by its last user
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// evict remove least recently used statements over capacity, statements not in use
+// are closed at once and pinned ones by release, rows of a closed statement are
+// still read as database/sql closes it after rows are closed
+func (c *stmtCache) evict() {
+	for c.ll.Len() > c.capacity {
+		e := c.ll.Back()
+		entry := e.Value.(*stmtEntry)
+		c.ll.Remove(e)
+		delete(c.items, entry.key)
+		entry.evicted = true
+		if entry.refs == 0 {
+			entry.stmt.Close()
+		}
+		c.evictions++
+	}
+}
+
+func (c *stmtCache) resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = capacity
+	c.evict()
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return StmtCacheStats{
+		Size:      c.ll.Len(),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// close close all cached statements
+func (c *stmtCache) close() {
+	c.resize(0)
+}
@@ -0,0 +1,169 @@
+package mini_orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDriver driver of statements that do nothing and match one row, statements of
+// table "missing" fail, it is for tests of statement cache and state of records
+type stubDriver struct{}
+
+type stubConn struct{}
+
+type stubStmt struct{ query string }
+
+type stubRows struct{ done bool }
+
+type stubTx struct{}
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+func (stubConn) Prepare(query string) (driver.Stmt, error) { return stubStmt{query}, nil }
+func (stubConn) Close() error                              { return nil }
+func (stubConn) Begin() (driver.Tx, error)                 { return stubTx{}, nil }
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+func (stubStmt) Close() error  { return nil }
+func (stubStmt) NumInput() int { return -1 }
+func (s stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "missing") {
+		return nil, fmt.Errorf("stub: relation missing does not exist")
+	}
+	return driver.RowsAffected(1), nil
+}
+func (stubStmt) Query([]driver.Value) (driver.Rows, error) { return &stubRows{}, nil }
+
+func (r *stubRows) Columns() []string { return []string{"count"} }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+// failingBook model of table "missing", statements of stub driver fail on it
+type failingBook struct {
+	ID        int64      `sql:"pk"`
+	UpdatedAt time.Time  `sql:"autoUpdateTime"`
+	DeletedAt *time.Time `sql:"deletedAt"`
+}
+
+func (b *failingBook) TableName() string {
+	return "missing"
+}
+
+// stubEngine return engine of stub driver, its clock is fixed at now unless now is zero
+func stubEngine(t *testing.T, now time.Time) *Engine {
+	t.Helper()
+	engine, err := NewEngine("stub", "stub?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !now.IsZero() {
+		engine.SetClock(func() time.Time { return now })
+	}
+	return engine
+}
+
+func TestStmtCacheConcurrentEviction(t *testing.T) {
+	engine := stubEngine(t, time.Time{})
+	engine.SetStmtCacheSize(1)
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				stmt, release, err := engine.stmtCache.get(context.Background(), "master", engine.Master(), fmt.Sprintf("SELECT %d", (g+i)%3))
+				if err != nil {
+					errs <- err
+					return
+				}
+				// let other goroutines evict the statement before it is used
+				runtime.Gosched()
+				_, err = stmt.Exec()
+				release()
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Equal(t, err, nil)
+	}
+	stats := engine.StmtCacheStats()
+	assert.Equal(t, stats.Size, 1)
+
+	result, err := engine.NewSession().ExecContext(context.Background(), "DELETE FROM codebook")
+	assert.Equal(t, err, nil)
+	n, _ := result.RowsAffected()
+	assert.Equal(t, n, int64(1))
+	count, err := engine.NewSession().Select().From("codebook").Count()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+}
+
+func TestStmtCacheSQLComment(t *testing.T) {
+	engine := stubEngine(t, time.Time{})
+	engine.SetStmtCacheSize(1)
+	for _, id := range []string{"1", "2"} {
+		ctx := WithSQLComment(context.Background(), "request_id", id)
+		count, err := engine.NewSessionCtx(ctx).Select().From("codebook").Count()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, count, int64(1))
+	}
+	assert.Equal(t, engine.StmtCacheStats(), StmtCacheStats{Capacity: 1})
+
+	for i := 0; i < 2; i++ {
+		_, err := engine.NewSession().Select().From("codebook").Comment("caller", "TestStmtCacheSQLComment").Count()
+		assert.Equal(t, err, nil)
+	}
+	assert.Equal(t, engine.StmtCacheStats(), StmtCacheStats{Size: 1, Capacity: 1, Hits: 1, Misses: 1})
+}
+
+func TestStmtCacheTransaction(t *testing.T) {
+	engine := stubEngine(t, time.Time{})
+	engine.SetStmtCacheSize(1)
+	engine.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// a miss in transaction is prepared by the transaction, not on a second connection
+	_, err := engine.NewSessionCtx(ctx).Transaction(func(s *Session) (interface{}, error) {
+		return s.Select().From("codebook").Count()
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, engine.StmtCacheStats().Size, 0)
+
+	_, err = engine.NewSessionCtx(ctx).Select().From("codebook").Count()
+	assert.Equal(t, err, nil)
+	_, err = engine.NewSessionCtx(ctx).Transaction(func(s *Session) (interface{}, error) {
+		return s.Select().From("codebook").Count()
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, engine.StmtCacheStats().Hits, uint64(1))
+}
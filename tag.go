@@ -0,0 +1,242 @@
+package mini_orm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// options of `sql` struct tag, options are separated by comma e.g.
+// `sql:"pk,autoincr,column=id"` or `sql:"type=numeric(10,2),notnull,default=0"`
+//
+//	pk              primary key
+//	autoincr        generated by database, zero value is not inserted
+//	column=         column name, default is snake case of field name
+//	columnName=     same as column=, kept for compatibility
+//	readOnly        never inserted or updated e.g. column with database default
+//	-               field is not a column, it can not be combined with other options
+//	omitempty       zero value is not inserted or updated
+//	default=        default value of column
+//	type=           database type of column e.g. varchar, numeric(10,2)
+//	size=           size of column, positive integer
+//	index           column is indexed
+//	unique          column is unique
+//	notnull         column is not null
+//	autoCreateTime  set to now by Insert if zero value, never updated
+//	autoUpdateTime  set to now by Insert if zero value and by every Update
+//	                time.Time fields get time truncated by DB.SetTimePrecision, integer
+//	                fields get unix time in seconds, or autoCreateTime=milli, =nano
+//	deletedAt       soft delete, Delete set it to now and queries skip rows with it set,
+//	                it is NULL or 0 for rows not deleted, unit like autoCreateTime
+//	prefix=         flatten fields of struct field with column prefix e.g. prefix=author_,
+//	                fields of anonymous embedded struct are flattened without it
+//
+// commas in parentheses or single quotes are part of option value
+const (
+	dbPk         = "pk"
+	dbAutoIncr   = "autoincr"
+	dbColumn     = "column"
+	dbColumnName = "columnName"
+	dbReadonly   = "readOnly"
+	dbIgnore     = "-"
+	dbOmitEmpty  = "omitempty"
+	dbDefault    = "default"
+	dbType       = "type"
+	dbSize       = "size"
+	dbIndex      = "index"
+	dbUnique     = "unique"
+	dbNotNull    = "notnull"
+	dbPrefix     = "prefix"
+	dbCreateTime = "autoCreateTime"
+	dbUpdateTime = "autoUpdateTime"
+	dbDeletedAt  = "deletedAt"
+)
+
+// timeOptions options with optional unit of integer field
+var timeOptions = map[string]bool{
+	dbCreateTime: true,
+	dbUpdateTime: true,
+	dbDeletedAt:  true,
+}
+
+// timeUnits units of integer autoCreateTime, autoUpdateTime and deletedAt fields
+var timeUnits = map[string]time.Duration{
+	"":      time.Second,
+	"unix":  time.Second,
+	"milli": time.Millisecond,
+	"nano":  time.Nanosecond,
+}
+
+// tagValueOptions options in form of key=value, others are flags
+var tagValueOptions = map[string]bool{
+	dbColumn:     true,
+	dbColumnName: true,
+	dbDefault:    true,
+	dbType:       true,
+	dbSize:       true,
+	dbPrefix:     true,
+}
+
+// tagFlagOptions options without value
+var tagFlagOptions = map[string]bool{
+	dbPk:        true,
+	dbAutoIncr:  true,
+	dbReadonly:  true,
+	dbIgnore:    true,
+	dbOmitEmpty: true,
+	dbIndex:     true,
+	dbUnique:    true,
+	dbNotNull:   true,
+}
+
+// parseTag parse `sql` tag of struct field df into field, it returns true if
+// the field is ignored by "-"
+func parseTag(t reflect.Type, df reflect.StructField, field *Field) (bool, error) {
+	tag := df.Tag.Get("sql")
+	if tag == "" {
+		return false, nil
+	}
+	invalid := func(reason string, args ...interface{}) error {
+		return &TagError{Model: t.Name(), Field: df.Name, Tag: tag, Reason: fmt.Sprintf(reason, args...)}
+	}
+	seen := make(map[string]bool)
+	for _, opt := range splitTag(tag) {
+		opt = strings.TrimSpace(opt)
+		kv := strings.SplitN(opt, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		switch {
+		case key == "":
+			return false, invalid("empty option")
+		case seen[key]:
+			return false, invalid("duplicate option %s", key)
+		case tagValueOptions[key] && len(kv) == 1:
+			return false, invalid("option %s expects value like %s=...", key, key)
+		case tagFlagOptions[key] && len(kv) == 2:
+			return false, invalid("option %s does not take value", key)
+		case timeOptions[key] && len(kv) == 2 && kv[1] == "":
+			return false, invalid("option %s expects unit unix, milli or nano", key)
+		case !tagValueOptions[key] && !tagFlagOptions[key] && !timeOptions[key]:
+			return false, invalid("unknown option %s", key)
+		}
+		seen[key] = true
+		if len(kv) == 2 {
+			field.Tags[key] = kv[1]
+		}
+		switch key {
+		case dbPk:
+			field.IsPrimaryKey = true
+		case dbAutoIncr:
+			field.IsAutoIncr = true
+		case dbReadonly:
+			field.IsReadOnly = true
+		case dbOmitEmpty:
+			field.OmitEmpty = true
+		case dbIndex:
+			field.Index = true
+		case dbUnique:
+			field.Unique = true
+		case dbNotNull:
+			field.NotNull = true
+		case dbColumn, dbColumnName:
+			name := strings.TrimSpace(kv[1])
+			if !identifier.MatchString(name) || strings.Contains(name, ".") {
+				return false, invalid("invalid column name %q", name)
+			}
+			field.Name = name
+		case dbDefault:
+			field.Default = kv[1]
+		case dbType:
+			if strings.TrimSpace(kv[1]) == "" {
+				return false, invalid("empty type")
+			}
+			field.Type = strings.TrimSpace(kv[1])
+		case dbCreateTime, dbUpdateTime, dbDeletedAt:
+			unit, ok := timeUnits[field.Tags[key]]
+			if !ok {
+				return false, invalid("option %s expects unit unix, milli or nano, got %q", key, field.Tags[key])
+			}
+			field.timeUnit = unit
+			field.AutoCreateTime = field.AutoCreateTime || key == dbCreateTime
+			field.AutoUpdateTime = field.AutoUpdateTime || key == dbUpdateTime
+			field.DeletedAt = field.DeletedAt || key == dbDeletedAt
+		case dbSize:
+			size, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+			if err != nil || size <= 0 {
+				return false, invalid("size expects positive integer, got %q", kv[1])
+			}
+			field.Size = size
+		}
+	}
+	switch {
+	case seen[dbIgnore] && len(seen) > 1:
+		return false, invalid("- can not be combined with other options")
+	case seen[dbColumn] && seen[dbColumnName]:
+		return false, invalid("column and columnName are both set")
+	case seen[dbAutoIncr] && !isInteger(df.Type):
+		return false, invalid("autoincr expects integer field, got %s", df.Type)
+	case seen[dbAutoIncr] && seen[dbDefault]:
+		return false, invalid("autoincr conflicts with default")
+	case seen[dbPrefix] && len(seen) > 1:
+		return false, invalid("prefix can not be combined with other options")
+	case seen[dbPrefix] && !prefixPattern.MatchString(field.Tags[dbPrefix]):
+		return false, invalid("invalid prefix %q", field.Tags[dbPrefix])
+	case (seen[dbCreateTime] || seen[dbUpdateTime] || seen[dbDeletedAt]) && !isTimestamp(df.Type):
+		return false, invalid("auto time expects time.Time, *time.Time or integer field, got %s", df.Type)
+	case (field.Tags[dbCreateTime] != "" || field.Tags[dbUpdateTime] != "" || field.Tags[dbDeletedAt] != "") && !isInteger(df.Type):
+		return false, invalid("time unit expects integer field, got %s", df.Type)
+	case (seen[dbCreateTime] || seen[dbUpdateTime] || seen[dbDeletedAt]) && (seen[dbReadonly] || seen[dbPk]):
+		return false, invalid("auto time conflicts with readOnly and pk")
+	case seen[dbCreateTime] && seen[dbUpdateTime]:
+		return false, invalid("autoCreateTime conflicts with autoUpdateTime")
+	case seen[dbDeletedAt] && (seen[dbCreateTime] || seen[dbUpdateTime]):
+		return false, invalid("deletedAt conflicts with autoCreateTime and autoUpdateTime")
+	case seen[dbPk] && seen[dbOmitEmpty]:
+		return false, invalid("pk conflicts with omitempty, use autoincr for generated key")
+	}
+	return seen[dbIgnore], nil
+}
+
+// prefixPattern valid column prefix of flattened struct
+var prefixPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// splitTag split tag by comma, commas in parentheses or single quotes are kept
+func splitTag(tag string) []string {
+	opts := make([]string, 0)
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			opts = append(opts, tag[start:i])
+			start = i + 1
+		}
+	}
+	return append(opts, tag[start:])
+}
+
+// isTimestamp return true if t is time.Time, *time.Time or integer
+func isTimestamp(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Ptr && t.Elem() == timeType) || isInteger(t)
+}
+
+// isInteger return true if t is integer or pointer to integer
+func isInteger(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package mini_orm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taggedBook struct {
+	ID       int64   `sql:"pk,autoincr,column=book_id"`
+	Title    string  `sql:"columnName=name,size=128,notnull,unique"`
+	Price    float64 `sql:"type=numeric(10,2),default='0,00',index"`
+	Remarks  string  `sql:"omitempty"`
+	Internal string  `sql:"-"`
+	Created  string  `sql:"readOnly"`
+	secret   string
+}
+
+type badTagBook struct {
+	ID   int64  `sql:"pk"`
+	Name string `sql:"size=big"`
+}
+
+func TestParseTag(t *testing.T) {
+	m, err := modelOf(reflect.TypeOf(taggedBook{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, m.PkName, "book_id")
+	assert.Equal(t, len(m.Fields), 5)
+	id := m.Fields["book_id"]
+	assert.True(t, id.IsPrimaryKey && id.IsAutoIncr)
+	title := m.Fields["name"]
+	assert.Equal(t, title.Size, 128)
+	assert.True(t, title.NotNull && title.Unique)
+	price := m.Fields["price"]
+	assert.Equal(t, price.Type, "numeric(10,2)")
+	assert.Equal(t, price.Default, "'0,00'")
+	assert.True(t, price.Index)
+	assert.True(t, m.Fields["remarks"].OmitEmpty)
+	assert.True(t, m.Fields["created"].IsReadOnly)
+	_, ok := m.Fields["internal"]
+	assert.False(t, ok)
+
+	err = Register(&badTagBook{})
+	assert.True(t, errors.Is(err, InvalidTag))
+	assert.Equal(t, err.Error(), `model badTagBook: invalid sql tag "size=big" of badTagBook.Name: size expects positive integer, got "big"`)
+	_, err = NewScanner(&badTagBook{})
+	assert.True(t, errors.Is(err, InvalidTag))
+
+	cases := map[string]string{
+		`sql:"pk,pk"`:                 "duplicate option pk",
+		`sql:"pk=1"`:                  "option pk does not take value",
+		`sql:"column"`:                "option column expects value like column=...",
+		`sql:"primary"`:               "unknown option primary",
+		`sql:"-,readOnly"`:            "- can not be combined with other options",
+		`sql:"column=a,columnName=b"`: "column and columnName are both set",
+		`sql:"column=a b"`:            `invalid column name "a b"`,
+		`sql:"autoincr,default=1"`:    "autoincr conflicts with default",
+		`sql:"pk,omitempty"`:          "pk conflicts with omitempty, use autoincr for generated key",
+		`sql:"readOnly,,notnull"`:     "empty option",
+	}
+	for tag, reason := range cases {
+		df := reflect.StructField{Name: "ID", Type: reflect.TypeOf(int64(0)), Tag: reflect.StructTag(tag)}
+		_, err := parseTag(reflect.TypeOf(badTagBook{}), df, &Field{Tags: make(map[string]string)})
+		assert.Equal(t, err.(*TagError).Reason, reason, tag)
+	}
+	df := reflect.StructField{Name: "Name", Type: reflect.TypeOf(""), Tag: `sql:"autoincr"`}
+	_, err = parseTag(reflect.TypeOf(badTagBook{}), df, &Field{Tags: make(map[string]string)})
+	assert.Equal(t, err.(*TagError).Reason, "autoincr expects integer field, got string")
+}
+
+type BaseModel struct {
+	ID        int64 `sql:"pk,autoincr"`
+	CreatedAt time.Time
+}
+
+type auditInfo struct {
+	By string
+	At *time.Time
+}
+
+// legacyModel has column id like BaseModel, models embedding both are ambiguous
+type legacyModel struct {
+	ID   int64
+	Note string
+}
+
+type embeddedBook struct {
+	*BaseModel
+	Name    string
+	Updated auditInfo `sql:"prefix=updated_"`
+}
+
+func TestEmbeddedModel(t *testing.T) {
+	m, err := modelOf(reflect.TypeOf(embeddedBook{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, m.PkName, "id")
+	names := make([]string, 0)
+	for _, f := range m.writableFields() {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, names, []string{"id", "created_at", "name", "updated_by", "updated_at"})
+
+	b := &embeddedBook{Name: "laojun"}
+	assert.Equal(t, m.Fields["id"].value(reflect.ValueOf(b).Elem()).Interface(), int64(0))
+	assert.True(t, b.BaseModel == nil)
+
+	id, by := int64(7), "liubin"
+	sc := &Scanner{fields: []string{"id", "name", "updated_by"}, Model: m}
+	err = sc.SetEntity([]interface{}{&id, &b.Name, &by}, reflect.ValueOf(b).Elem())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, b.ID, int64(7))
+	assert.Equal(t, b.Updated.By, "liubin")
+
+	type recursive struct {
+		*recursive
+		Name string
+	}
+	type badEmbedded struct {
+		BaseModel `sql:"readOnly"`
+	}
+	type badPrefix struct {
+		Name string `sql:"prefix=x_"`
+	}
+	type shadowed struct {
+		BaseModel
+		ID int64 `sql:"pk"`
+	}
+	type ambiguous struct {
+		BaseModel
+		legacyModel
+	}
+	type resolved struct {
+		BaseModel
+		legacyModel
+		ID int64
+	}
+	_, err = modelOf(reflect.TypeOf(badEmbedded{}))
+	assert.Equal(t, err.(*TagError).Reason, "embedded struct only supports prefix or -")
+	_, err = modelOf(reflect.TypeOf(badPrefix{}))
+	assert.Equal(t, err.(*TagError).Reason, "prefix expects struct field")
+	// outer field shadows field of embedded struct, only fields at the same depth conflict
+	m, err = modelOf(reflect.TypeOf(shadowed{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, m.Fields["id"].index, []int{1})
+	assert.Equal(t, m.PrimaryKeys, []*Field{m.Fields["id"]})
+	assert.False(t, m.Fields["id"].IsAutoIncr)
+	_, err = modelOf(reflect.TypeOf(ambiguous{}))
+	assert.Equal(t, err.(*TagError).Field, "ID")
+	assert.Equal(t, err.(*TagError).Reason, "column id is also used by field ID")
+	m, err = modelOf(reflect.TypeOf(resolved{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, m.Fields["id"].index, []int{2})
+	assert.Equal(t, m.PkName, "")
+	// unexported embedded pointer is skipped as it can not be allocated
+	_, err = modelOf(reflect.TypeOf(recursive{}))
+	assert.Equal(t, err, nil)
+	_, err = modelOf(reflect.TypeOf(Recursive{}))
+	assert.Equal(t, err.(*TagError).Reason, "recursive struct can not be flattened")
+}
+
+// Recursive exported struct embedding itself, it can not be flattened
+type Recursive struct {
+	*Recursive
+	Name string
+}
@@ -0,0 +1,87 @@
+package mini_orm
+
+import (
+	"reflect"
+	"time"
+)
+
+// SetClock set clock of autoCreateTime and autoUpdateTime fields, default is time.Now,
+// it is meant to be set once e.g. fixed time in tests
+func (db *DB) SetClock(clock func() time.Time) {
+	db.clock = clock
+}
+
+// SetTimePrecision truncate time of autoCreateTime and autoUpdateTime time.Time fields
+// e.g. time.Microsecond for postgres timestamp, 0 keep time as it is
+func (db *DB) SetTimePrecision(precision time.Duration) {
+	db.precision = precision
+}
+
+// now return time of clock truncated by time precision
+func (db *DB) now() time.Time {
+	clock := db.clock
+	if clock == nil {
+		clock = time.Now
+	}
+	now := clock()
+	if db.precision > 0 {
+		now = now.Truncate(db.precision)
+	}
+	return now
+}
+
+// setTimestamps set autoCreateTime and autoUpdateTime fields of entities to now, create
+// is true for Insert which only set zero values, Update always set autoUpdateTime.
+// entities not addressable are copied so values are still used by the statement,
+// restore put previous values back e.g. when statement failed or in dry run mode
+func (s *Session) setTimestamps(m *Model, entities []reflect.Value, create bool) ([]reflect.Value, func()) {
+	fields := make([]*Field, 0)
+	for _, f := range m.Fields {
+		if (create && f.AutoCreateTime) || f.AutoUpdateTime {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return entities, func() {}
+	}
+	now := s.db.now()
+	previous := make([]reflect.Value, 0)
+	for i, entity := range entities {
+		if !entity.CanAddr() {
+			c := reflect.New(entity.Type()).Elem()
+			c.Set(entity)
+			entity = c
+			entities[i] = c
+		}
+		for _, f := range fields {
+			if create && !f.value(entity).IsZero() {
+				continue
+			}
+			fv := f.settable(entity)
+			old := reflect.New(fv.Type()).Elem()
+			old.Set(fv)
+			previous = append(previous, fv, old)
+			setTime(fv, f.timeUnit, now)
+		}
+	}
+	return entities, func() {
+		for i := 0; i < len(previous); i += 2 {
+			previous[i].Set(previous[i+1])
+		}
+	}
+}
+
+// setTime set time.Time, *time.Time or integer fv to t, integer is unix time in unit
+func setTime(fv reflect.Value, unit time.Duration, t time.Time) {
+	switch {
+	case fv.Type() == timeType:
+		fv.Set(reflect.ValueOf(t))
+	case fv.Kind() == reflect.Ptr:
+		fv.Set(reflect.New(fv.Type().Elem()))
+		setTime(fv.Elem(), unit, t)
+	case fv.Kind() >= reflect.Int && fv.Kind() <= reflect.Int64:
+		fv.SetInt(t.UnixNano() / int64(unit))
+	default:
+		fv.SetUint(uint64(t.UnixNano() / int64(unit)))
+	}
+}
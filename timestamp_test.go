@@ -0,0 +1,83 @@
+package mini_orm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stampedBook struct {
+	ID        int64 `sql:"pk,autoincr"`
+	Name      string
+	CreatedAt time.Time  `sql:"autoCreateTime"`
+	UpdatedAt *time.Time `sql:"autoUpdateTime"`
+	Touched   int64      `sql:"autoUpdateTime=milli"`
+}
+
+func (b *stampedBook) TableName() string {
+	return "stamped_book"
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 891234567, time.UTC)
+	db := &DB{dialect: PostgresDialect}
+	db.SetClock(func() time.Time { return now })
+	db.SetTimePrecision(time.Microsecond)
+	s := dryRunSession(db)
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	books := []*stampedBook{{Name: "liubin"}, {Name: "laojun", CreatedAt: created}}
+	_, err := s.Insert(&books)
+	assert.Equal(t, err, nil)
+	truncated := now.Truncate(time.Microsecond)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{
+		SQL: "INSERT INTO stamped_book (name,created_at,updated_at,touched) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)",
+		Args: []interface{}{"liubin", truncated, &truncated, int64(1614834367891),
+			"laojun", created, &truncated, int64(1614834367891)},
+	})
+	assert.Equal(t, books[0].CreatedAt, time.Time{})
+	assert.True(t, books[0].UpdatedAt == nil)
+
+	later := now.Add(time.Hour).Truncate(time.Microsecond)
+	db.SetClock(func() time.Time { return later })
+	_, err = s.Update(stampedBook{ID: 1, Name: "nami", CreatedAt: created})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lastDryRun(t, s), DryRunStatement{
+		SQL:  "UPDATE stamped_book SET name = $1, touched = $2, updated_at = $3 WHERE id = $4",
+		Args: []interface{}{"nami", later.UnixNano() / int64(time.Millisecond), &later, int64(1)},
+	})
+}
+
+func TestTimestampTags(t *testing.T) {
+	_, err := modelOf(reflect.TypeOf(struct {
+		Name string `sql:"autoCreateTime"`
+	}{}))
+	assert.Equal(t, err.(*TagError).Reason, "auto time expects time.Time, *time.Time or integer field, got string")
+	_, err = modelOf(reflect.TypeOf(struct {
+		At int64 `sql:"autoUpdateTime=micro"`
+	}{}))
+	assert.Equal(t, err.(*TagError).Reason, `option autoUpdateTime expects unit unix, milli or nano, got "micro"`)
+	_, err = modelOf(reflect.TypeOf(struct {
+		At *time.Time `sql:"autoCreateTime=milli"`
+	}{}))
+	assert.Equal(t, err.(*TagError).Reason, "time unit expects integer field, got *time.Time")
+}
+
+func TestTimestampsField(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	engine := stubEngine(t, now)
+
+	book := &stampedBook{Name: "liubin"}
+	_, err := engine.NewSession().Insert(book)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, book.CreatedAt, now)
+	assert.Equal(t, *book.UpdatedAt, now)
+
+	updated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	missing := &failingBook{ID: 1, UpdatedAt: updated}
+	_, err = engine.NewSession().Update(missing)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, missing.UpdatedAt, updated)
+}
@@ -0,0 +1,105 @@
+package mini_orm
+
+import (
+	"reflect"
+	"sort"
+)
+
+// validateColumns check columns used in Where, Columns and OrderBy are fields of model,
+// expressions e.g. count(*) are not checked
+func (st *Statement) validateColumns(m *Model) error {
+	unknown := make([]string, 0)
+	seen := make(map[string]bool)
+	check := func(column string) {
+		if !identifier.MatchString(column) {
+			return
+		}
+		name := unqualified(column)
+		if _, ok := m.Fields[name]; ok || seen[name] {
+			return
+		}
+		seen[name] = true
+		unknown = append(unknown, name)
+	}
+	for _, c := range st.columns {
+		check(c)
+	}
+	for _, j := range st.jsonCols {
+		column, _ := parseJSONPath(j.path)
+		check(column)
+	}
+	for _, c := range st.conditions {
+		for _, column := range conditionColumns(c.Expr) {
+			check(column)
+		}
+	}
+	for _, o := range st.orderBys {
+		if o.raw == "" {
+			check(o.column)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return newUnknownColumnError(m, unknown)
+}
+
+// conditionColumns return columns used by condition
+func conditionColumns(c interface{}) []string {
+	columns := make([]string, 0)
+	switch expr := c.(type) {
+	case Eq, Ne, Like, NotLike, GT, GTE, LT, LTE, AnyOf, ArrayContains, ArrayOverlaps:
+		for _, k := range reflect.ValueOf(expr).MapKeys() {
+			columns = append(columns, k.String())
+		}
+	case JSONEq, JSONContains, JSONHasKey:
+		for _, k := range reflect.ValueOf(expr).MapKeys() {
+			column, _ := parseJSONPath(k.String())
+			columns = append(columns, column)
+		}
+	case TupleIn:
+		columns = append(columns, expr.Columns...)
+	case AND:
+		for _, v := range expr {
+			columns = append(columns, conditionColumns(v)...)
+		}
+	case OR:
+		for _, v := range expr {
+			columns = append(columns, conditionColumns(v)...)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// closeMatches return fields of model similar to name, best first
+func closeMatches(m *Model, name string) []string {
+	type match struct {
+		field    string
+		distance int
+	}
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	matches := make([]match, 0)
+	for field := range m.Fields {
+		if d := levenshtein(name, field); d <= maxDistance {
+			matches = append(matches, match{field, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance == matches[j].distance {
+			return matches[i].field < matches[j].field
+		}
+		return matches[i].distance < matches[j].distance
+	})
+	fields := make([]string, 0, len(matches))
+	for i, mt := range matches {
+		if i == 3 {
+			break
+		}
+		fields = append(fields, mt.field)
+	}
+	return fields
+}
@@ -0,0 +1,119 @@
+package mini_orm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WindowExpr window function used as select column
+// e.g. RowNumber().PartitionBy("name").OrderBy("id desc").As("rn")
+// => ROW_NUMBER() OVER (PARTITION BY name ORDER BY id desc) AS rn
+// alias is mapped by Scanner like any other column, the struct field should be readOnly
+type WindowExpr struct {
+	function  string
+	partition []string
+	orderBys  []string
+	frame     string
+	alias     string
+}
+
+// Window return window expr with any function e.g. Window("NTILE(4)")
+func Window(function string) *WindowExpr {
+	return &WindowExpr{function: function}
+}
+
+// RowNumber ROW_NUMBER()
+func RowNumber() *WindowExpr {
+	return Window("ROW_NUMBER()")
+}
+
+// Rank RANK()
+func Rank() *WindowExpr {
+	return Window("RANK()")
+}
+
+// DenseRank DENSE_RANK()
+func DenseRank() *WindowExpr {
+	return Window("DENSE_RANK()")
+}
+
+// Lag e.g. Lag("price", 1) => LAG(price, 1)
+func Lag(column string, offset int) *WindowExpr {
+	return Window(fmt.Sprintf("LAG(%s, %d)", column, offset))
+}
+
+// Lead e.g. Lead("price", 1) => LEAD(price, 1)
+func Lead(column string, offset int) *WindowExpr {
+	return Window(fmt.Sprintf("LEAD(%s, %d)", column, offset))
+}
+
+// Sum e.g. Sum("amount").OrderBy("id") => running total
+func Sum(column string) *WindowExpr {
+	return Window(fmt.Sprintf("SUM(%s)", column))
+}
+
+// Avg AVG(column)
+func Avg(column string) *WindowExpr {
+	return Window(fmt.Sprintf("AVG(%s)", column))
+}
+
+// Min MIN(column)
+func Min(column string) *WindowExpr {
+	return Window(fmt.Sprintf("MIN(%s)", column))
+}
+
+// Max MAX(column)
+func Max(column string) *WindowExpr {
+	return Window(fmt.Sprintf("MAX(%s)", column))
+}
+
+// PartitionBy set PARTITION BY columns
+func (w *WindowExpr) PartitionBy(columns ...string) *WindowExpr {
+	w.partition = append(w.partition, columns...)
+	return w
+}
+
+// OrderBy set window ORDER BY
+func (w *WindowExpr) OrderBy(orderBys ...string) *WindowExpr {
+	w.orderBys = append(w.orderBys, orderBys...)
+	return w
+}
+
+// Frame set window frame e.g. "ROWS BETWEEN 2 PRECEDING AND CURRENT ROW"
+func (w *WindowExpr) Frame(frame string) *WindowExpr {
+	w.frame = frame
+	return w
+}
+
+// As set column alias
+func (w *WindowExpr) As(alias string) *WindowExpr {
+	w.alias = alias
+	return w
+}
+
+// Clone return copy of window expr
+func (w *WindowExpr) Clone() *WindowExpr {
+	c := *w
+	c.partition = append(make([]string, 0, len(w.partition)), w.partition...)
+	c.orderBys = append(make([]string, 0, len(w.orderBys)), w.orderBys...)
+	return &c
+}
+
+// String render window expr as select column
+func (w *WindowExpr) String() string {
+	over := make([]string, 0, 3)
+	if len(w.partition) > 0 {
+		over = append(over, "PARTITION BY "+strings.Join(w.partition, ", "))
+	}
+	if len(w.orderBys) > 0 {
+		over = append(over, "ORDER BY "+strings.Join(w.orderBys, ", "))
+	}
+	if w.frame != "" {
+		over = append(over, w.frame)
+	}
+	expr := fmt.Sprintf("%s OVER (%s)", w.function, strings.Join(over, " "))
+	if w.alias != "" {
+		expr += " AS " + w.alias
+	}
+	return expr
+}